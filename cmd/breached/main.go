@@ -0,0 +1,66 @@
+// breached 将泄露密码列表转换为服务端使用的哈希前缀文件
+//
+// 输入每行一条，默认为 Have I Been Pwned 格式的 SHA-1 哈希（可以带有 :出现次数 后缀），
+// 使用 -plain 时每行为一个明文密码。输出文件通过环境变量 BREACHED_PASSWORD_FILE 配置给服务端。
+//
+//	go run ./cmd/breached -o breached.bin < pwned-passwords-sha1.txt
+package main
+
+import (
+	"Gin-IM/pkg/validates"
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+func main() {
+	output := flag.String("o", "breached.bin", "输出文件")
+	plain := flag.Bool("plain", false, "输入为明文密码")
+	flag.Parse()
+
+	var prefixes []uint64
+	scanner := bufio.NewScanner(os.Stdin)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		if *plain {
+			prefixes = append(prefixes, validates.BreachedPrefix(text))
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		if len(hash) < 16 {
+			fmt.Fprintf(os.Stderr, "第 %d 行不是 SHA-1 哈希\n", line)
+			os.Exit(1)
+		}
+		prefix, err := hex.DecodeString(hash[:16])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "第 %d 行不是 SHA-1 哈希\n", line)
+			os.Exit(1)
+		}
+		prefixes = append(prefixes, binary.BigEndian.Uint64(prefix))
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slices.Sort(prefixes)
+	prefixes = slices.Compact(prefixes)
+
+	data := make([]byte, len(validates.BreachedMagic), len(validates.BreachedMagic)+len(prefixes)*8)
+	copy(data, validates.BreachedMagic)
+	for _, prefix := range prefixes {
+		data = binary.BigEndian.AppendUint64(data, prefix)
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("已写入 %d 条\n", len(prefixes))
+}
@@ -15,9 +15,29 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/account/getcaptcha": {
+        "/.well-known/jwks.json": {
             "get": {
-                "description": "获取验证码",
+                "description": "以 JWKS 格式返回所有有效的验签公钥，其他服务按令牌头部的 kid 选择公钥在本地校验令牌",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "获取令牌验签公钥",
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/types.JWKS"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/2fa/disable": {
+            "post": {
+                "description": "提供密码与动态验证码（或恢复码）后关闭两步验证",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,9 +45,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "账户管理"
+                    "两步验证"
+                ],
+                "summary": "关闭两步验证",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "密码与验证码",
+                        "name": "two_factor_disable",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.TwoFactorDisable"
+                        }
+                    }
                 ],
-                "summary": "获取验证码",
                 "responses": {
                     "200": {
                         "description": "返回结果",
@@ -38,9 +76,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/account/getuserinfo": {
-            "get": {
-                "description": "获取用户信息",
+        "/api/account/2fa/enable": {
+            "post": {
+                "description": "使用身份验证器上的第一个动态验证码确认密钥，返回只显示一次的恢复码",
                 "consumes": [
                     "application/json"
                 ],
@@ -48,9 +86,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "账户管理"
+                    "两步验证"
                 ],
-                "summary": "获取用户信息",
+                "summary": "开启两步验证",
                 "parameters": [
                     {
                         "type": "string",
@@ -58,6 +96,15 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "动态验证码",
+                        "name": "two_factor_code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.TwoFactorCode"
+                        }
                     }
                 ],
                 "responses": {
@@ -70,9 +117,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/account/login": {
+        "/api/account/2fa/recovery": {
             "post": {
-                "description": "处理用户登录请求。",
+                "description": "使用动态验证码确认后生成新的恢复码，旧的恢复码全部作废",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,17 +127,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "账户管理"
+                    "两步验证"
                 ],
-                "summary": "登陆",
+                "summary": "重新生成恢复码",
                 "parameters": [
                     {
-                        "description": "登录信息",
-                        "name": "login",
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "动态验证码",
+                        "name": "two_factor_code",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.Login"
+                            "$ref": "#/definitions/request.TwoFactorCode"
                         }
                     }
                 ],
@@ -104,9 +158,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/account/logout": {
-            "get": {
-                "description": "退出登录",
+        "/api/account/2fa/setup": {
+            "post": {
+                "description": "生成 TOTP 密钥并返回 otpauth 地址与二维码，使用动态验证码确认之前不会生效",
                 "consumes": [
                     "application/json"
                 ],
@@ -114,9 +168,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "账户管理"
+                    "两步验证"
                 ],
-                "summary": "退出登录",
+                "summary": "生成两步验证密钥",
                 "parameters": [
                     {
                         "type": "string",
@@ -136,9 +190,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/account/register": {
+        "/api/account/2fa/verify": {
             "post": {
-                "description": "处理用户注册请求",
+                "description": "使用登录接口返回的登录挑战与动态验证码（或恢复码）完成登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -146,17 +200,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "账户管理"
+                    "两步验证"
                 ],
-                "summary": "注册",
+                "summary": "两步验证登录",
                 "parameters": [
                     {
-                        "description": "注册信息",
-                        "name": "register",
+                        "description": "登录挑战与验证码",
+                        "name": "two_factor_login",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.Register"
+                            "$ref": "#/definitions/request.TwoFactorLogin"
                         }
                     }
                 ],
@@ -170,11 +224,11 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/account/search": {
+        "/api/account/avatar": {
             "post": {
-                "description": "搜索用户",
+                "description": "上传 jpeg/png/gif/webp 图片，服务端按裁剪区域裁剪为正方形并缩放后保存。返回的头像对象名称可以通过 /api/{avatar} 访问",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -182,7 +236,7 @@ const docTemplate = `{
                 "tags": [
                     "账户管理"
                 ],
-                "summary": "搜索用户",
+                "summary": "上传头像",
                 "parameters": [
                     {
                         "type": "string",
@@ -192,13 +246,29 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "搜索信息",
-                        "name": "userSearch",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.UserSearch"
-                        }
+                        "type": "file",
+                        "description": "头像图片",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "裁剪区域左上角横坐标",
+                        "name": "x",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "裁剪区域左上角纵坐标",
+                        "name": "y",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "裁剪区域边长，为0时从中心裁剪",
+                        "name": "size",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -211,9 +281,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/delete": {
+        "/api/account/delete": {
             "post": {
-                "description": "删除文件",
+                "description": "验证密码（开启两步验证时还需要动态验证码）后注销账号，账号立即停用，宽限期内可以恢复，之后数据将被彻底清理",
                 "consumes": [
                     "application/json"
                 ],
@@ -221,30 +291,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "删除文件",
+                "summary": "注销账号",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "文件删除信息",
-                        "name": "fileDeletes",
+                        "description": "密码与动态验证码",
+                        "name": "account_delete",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FileDeletes"
+                            "$ref": "#/definitions/request.AccountDelete"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -252,9 +322,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/download": {
+        "/api/account/forgotpassword": {
             "post": {
-                "description": "获取文件下载地址",
+                "description": "向邮箱发送一次性的重置密码令牌，无论邮箱是否存在都返回相同的结果",
                 "consumes": [
                     "application/json"
                 ],
@@ -262,30 +332,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "获取文件下载地址",
+                "summary": "忘记密码",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bearer Token令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "文件下载信息",
-                        "name": "fileDownload",
+                        "description": "邮箱与图形验证码",
+                        "name": "password_forgot",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FileDownload"
+                            "$ref": "#/definitions/request.PasswordForgot"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败受到",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -293,9 +356,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/merge": {
-            "post": {
-                "description": "合并文件",
+        "/api/account/getcaptcha": {
+            "get": {
+                "description": "获取验证码，类型由服务端配置决定：digit、string、math 为图片，audio 为音频，pow 为工作量证明挑战",
                 "consumes": [
                     "application/json"
                 ],
@@ -303,30 +366,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "合并文件",
+                "summary": "获取验证码",
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/getuserinfo": {
+            "get": {
+                "description": "获取用户信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "获取用户信息",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/login": {
+            "post": {
+                "description": "处理用户登录请求。在登录成功过的设备与IP上、且没有失败记录时可以不提供验证码，否则返回 1030 要求完成验证码。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "登陆",
+                "parameters": [
                     {
-                        "description": "文件合并信息",
-                        "name": "merge",
+                        "description": "登录信息",
+                        "name": "login",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FileMerge"
+                            "$ref": "#/definitions/request.Login"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -334,9 +445,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/pushparts": {
-            "post": {
-                "description": "上传分片信息",
+        "/api/account/logout": {
+            "get": {
+                "description": "退出登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -344,30 +455,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "上传分片信息",
+                "summary": "退出登录",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "分片信息",
-                        "name": "partInfo",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.PartInfo"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -375,9 +477,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/recovery": {
+        "/api/account/password": {
             "post": {
-                "description": "恢复文件",
+                "description": "验证原密码后设置新密码，当前设备以外的会话都会被注销",
                 "consumes": [
                     "application/json"
                 ],
@@ -385,30 +487,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "恢复文件",
+                "summary": "修改密码",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "文件恢复信息",
-                        "name": "recoveries",
+                        "description": "原密码与新密码",
+                        "name": "password_change",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FileRecoveryList"
+                            "$ref": "#/definitions/request.PasswordChange"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -416,9 +518,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/file/trash": {
+        "/api/account/privacy": {
             "get": {
-                "description": "获取回收站文件列表",
+                "description": "获取当前用户的查找方式、好友申请策略与最后在线时间可见范围",
                 "consumes": [
                     "application/json"
                 ],
@@ -426,13 +528,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "获取回收站文件列表",
+                "summary": "获取隐私设置",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
@@ -440,17 +542,15 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     }
                 }
-            }
-        },
-        "/api/file/upload": {
+            },
             "post": {
-                "description": "上传文件",
+                "description": "修改当前用户的查找方式、好友申请策略与最后在线时间可见范围",
                 "consumes": [
                     "application/json"
                 ],
@@ -458,30 +558,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "文件管理"
+                    "账户管理"
                 ],
-                "summary": "上传文件",
+                "summary": "修改隐私设置",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "文件上传信息",
-                        "name": "fileUpload",
+                        "description": "隐私设置",
+                        "name": "privacy",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FileUpload"
+                            "$ref": "#/definitions/request.Privacy"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -489,9 +589,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/add": {
+        "/api/account/profile": {
             "post": {
-                "description": "添加好友",
+                "description": "修改用户名与昵称，字段为空时保持不变",
                 "consumes": [
                     "application/json"
                 ],
@@ -499,52 +599,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "添加好友",
+                "summary": "修改个人资料",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "friend_request",
-                        "name": "friend_request",
+                        "description": "个人资料",
+                        "name": "profile",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FriendRequest"
+                            "$ref": "#/definitions/request.ProfileUpdate"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "string"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/response.Response"
                         }
                     }
                 }
             }
         },
-        "/api/friend/agree": {
+        "/api/account/refresh": {
             "post": {
-                "description": "同意好友请求",
+                "description": "使用刷新令牌换取新的访问令牌，刷新令牌每次使用后都会更换，旧令牌再次使用会导致该设备的会话被注销",
                 "consumes": [
                     "application/json"
                 ],
@@ -552,30 +640,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "同意好友请求",
+                "summary": "刷新令牌",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bearer Token令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
+                        "description": "刷新令牌",
+                        "name": "refresh_token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RefreshToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/register": {
+            "post": {
+                "description": "处理用户注册请求",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "注册",
+                "parameters": [
                     {
-                        "description": "好友信息",
-                        "name": "friend_request",
+                        "description": "注册信息",
+                        "name": "register",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FriendRequest"
+                            "$ref": "#/definitions/request.Register"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -583,9 +698,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/black": {
+        "/api/account/resendverify": {
             "post": {
-                "description": "拉黑好友",
+                "description": "向未验证的邮箱重新发送验证码，无论邮箱是否存在都返回相同的结果",
                 "consumes": [
                     "application/json"
                 ],
@@ -593,30 +708,132 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "拉黑好友",
+                "summary": "重新发送验证邮件",
+                "parameters": [
+                    {
+                        "description": "邮箱与图形验证码",
+                        "name": "email_resend",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.EmailResend"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/resetpassword": {
+            "post": {
+                "description": "使用邮件中的令牌设置新密码，成功后所有设备需要重新登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "重置密码",
+                "parameters": [
+                    {
+                        "description": "重置令牌与新密码",
+                        "name": "password_reset",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.PasswordReset"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/restore": {
+            "post": {
+                "description": "在宽限期内使用邮箱与密码恢复已注销的账号，恢复后需要重新登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "恢复账号",
+                "parameters": [
+                    {
+                        "description": "邮箱、密码与图形验证码",
+                        "name": "account_restore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.AccountRestore"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/search": {
+            "post": {
+                "description": "按邮箱精确搜索，或按用户名、昵称模糊搜索用户，返回分页的公开资料",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "搜索用户",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "好友信息",
-                        "name": "friend_request",
+                        "description": "搜索信息",
+                        "name": "userSearch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FriendRequest"
+                            "$ref": "#/definitions/request.UserSearch"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -624,9 +841,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/blacklist": {
+        "/api/account/sessions": {
             "get": {
-                "description": "查询拉黑列表",
+                "description": "列出当前用户所有有效的会话，包括设备名称、IP、User-Agent、登录时间与最后活跃时间",
                 "consumes": [
                     "application/json"
                 ],
@@ -634,13 +851,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "查询拉黑列表",
+                "summary": "获取登录设备列表",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
@@ -648,7 +865,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -656,9 +873,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/cancelblack": {
+        "/api/account/sessions/revoke": {
             "post": {
-                "description": "取消拉黑",
+                "description": "远程注销当前用户的一个会话，该会话的令牌立即失效",
                 "consumes": [
                     "application/json"
                 ],
@@ -666,30 +883,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "取消拉黑",
+                "summary": "注销指定设备",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "好友信息",
-                        "name": "friend_request",
+                        "description": "会话ID",
+                        "name": "session_revoke",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FriendRequest"
+                            "$ref": "#/definitions/request.SessionRevoke"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -697,9 +914,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/delete": {
+        "/api/account/sessions/revokeothers": {
             "post": {
-                "description": "删除好友",
+                "description": "注销当前设备以外的所有会话",
                 "consumes": [
                     "application/json"
                 ],
@@ -707,30 +924,62 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "删除好友",
+                "summary": "注销其他所有设备",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer Token令牌",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/account/signins": {
+            "post": {
+                "description": "分页列出当前用户的登录尝试，包括时间、IP、User-Agent、设备、是否成功与失败原因，suspicious 表示来自新设备或新网段的登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "获取登录记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "description": "好友信息",
-                        "name": "friend_request",
+                        "description": "分页信息",
+                        "name": "login_history",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.FriendRequest"
+                            "$ref": "#/definitions/request.LoginHistory"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
@@ -738,9 +987,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/friend/list": {
-            "get": {
-                "description": "获取好友列表",
+        "/api/account/verifyemail": {
+            "post": {
+                "description": "使用注册后邮件中的验证码激活账号",
                 "consumes": [
                     "application/json"
                 ],
@@ -748,259 +997,2697 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "好友"
+                    "账户管理"
                 ],
-                "summary": "获取好友列表",
+                "summary": "验证邮箱",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bearer Token令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "邮箱与验证码",
+                        "name": "email_verify",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.EmailVerify"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "失败",
+                        "description": "返回结果",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "request.FileDelete": {
-            "type": "object",
-            "required": [
-                "fileName",
-                "md5",
-                "sha1"
-            ],
-            "properties": {
-                "fileName": {
+        },
+        "/api/admin/ban": {
+            "post": {
+                "description": "管理员封禁用户并注销其所有会话，截止时间为空时永久封禁",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "封禁用户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户ID、封禁原因与截止时间",
+                        "name": "user_ban",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UserBan"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/role": {
+            "post": {
+                "description": "创建角色或替换已有角色的说明与权限，内置的 admin 角色不能修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "保存角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "角色名称、说明与权限标识",
+                        "name": "role_save",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RoleSave"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/role/assign": {
+            "post": {
+                "description": "为用户授予角色，权限在下一次请求时生效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "授予角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户ID与角色名称",
+                        "name": "role_assign",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RoleAssign"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/role/revoke": {
+            "post": {
+                "description": "撤销用户的角色，不能撤销自己的 admin 角色",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "撤销角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户ID与角色名称",
+                        "name": "role_revoke",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RoleAssign"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/roles": {
+            "get": {
+                "description": "列出所有角色及其拥有的权限标识",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "获取角色列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/signins": {
+            "post": {
+                "description": "管理员按用户ID、邮箱或IP查询所有用户的登录尝试，可以只查看可疑的登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "查询登录记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "查询条件与分页信息",
+                        "name": "login_history",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.AdminLoginHistory"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/unban": {
+            "post": {
+                "description": "管理员提前解除用户的封禁",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "解除封禁",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户ID",
+                        "name": "user_unban",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UserUnban"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/unlock": {
+            "post": {
+                "description": "管理员解除账号或IP因登录失败次数过多产生的锁定",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "解除登录锁定",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "邮箱或IP",
+                        "name": "login_unlock",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.LoginUnlock"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/avatar/{name}": {
+            "get": {
+                "description": "重定向到头像图片的预签名地址，头像对象名称形如 avatar/{userId}/{name}",
+                "tags": [
+                    "账户管理"
+                ],
+                "summary": "获取头像",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "头像对象名称中 avatar/ 之后的部分",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "302": {
+                        "description": "重定向到头像图片"
+                    }
+                }
+            }
+        },
+        "/api/bot/create": {
+            "post": {
+                "description": "创建一个属于当前用户的机器人账号，机器人只能通过 API 密钥访问",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "机器人"
+                ],
+                "summary": "创建机器人",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "用户名与昵称",
+                        "name": "bot_create",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.BotCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/key/create": {
+            "post": {
+                "description": "为机器人创建 API 密钥，权限范围目前只有 upload-file，密钥明文只返回这一次。请求时放在 X-Api-Key 请求头中",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "机器人"
+                ],
+                "summary": "创建API密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "机器人ID、密钥名称与权限范围",
+                        "name": "api_key_create",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ApiKeyCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/key/revoke": {
+            "post": {
+                "description": "吊销机器人的 API 密钥",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "机器人"
+                ],
+                "summary": "吊销API密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "密钥ID",
+                        "name": "api_key_target",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ApiKeyTarget"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/key/rotate": {
+            "post": {
+                "description": "生成新的密钥明文，权限范围不变，旧的密钥立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "机器人"
+                ],
+                "summary": "轮换API密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "密钥ID",
+                        "name": "api_key_target",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ApiKeyTarget"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/bot/list": {
+            "get": {
+                "description": "列出当前用户创建的机器人及其 API 密钥，不包含密钥明文",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "机器人"
+                ],
+                "summary": "获取机器人列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/delete": {
+            "post": {
+                "description": "删除文件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "删除文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件删除信息",
+                        "name": "fileDeletes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileDeletes"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/download": {
+            "post": {
+                "description": "获取文件下载地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "获取文件下载地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件下载信息",
+                        "name": "fileDownload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileDownload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败受到",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/list": {
+            "post": {
+                "description": "获取当前用户上传的文件，不包括回收站中的文件。可以按类型（image、video、audio、file）、状态与文件名筛选，按上传时间、文件名或大小排序，使用上一页返回的 nextCursor 获取下一页",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "获取文件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "筛选条件、排序方式与游标",
+                        "name": "fileList",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileList"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/merge": {
+            "post": {
+                "description": "合并文件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "合并文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件合并信息",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileMerge"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/pushparts": {
+            "post": {
+                "description": "上传分片信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "上传分片信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "分片信息",
+                        "name": "partInfo",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.PartInfo"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/recovery": {
+            "post": {
+                "description": "恢复文件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "恢复文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件恢复信息",
+                        "name": "recoveries",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileRecoveryList"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/trash": {
+            "get": {
+                "description": "获取回收站文件列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "获取回收站文件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/file/upload": {
+            "post": {
+                "description": "上传文件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "文件管理"
+                ],
+                "summary": "上传文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件上传信息",
+                        "name": "fileUpload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FileUpload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/add": {
+            "post": {
+                "description": "添加好友",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "添加好友",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "friend_request",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/agree": {
+            "post": {
+                "description": "同意好友请求",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "同意好友请求",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "好友信息",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/black": {
+            "post": {
+                "description": "拉黑好友",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "拉黑好友",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "好友信息",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/blacklist": {
+            "get": {
+                "description": "查询拉黑列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "查询拉黑列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/cancelblack": {
+            "post": {
+                "description": "取消拉黑",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "取消拉黑",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "好友信息",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/delete": {
+            "post": {
+                "description": "删除好友",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "删除好友",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "好友信息",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/discover": {
+            "post": {
+                "description": "上传通讯录中邮箱的加盐哈希，返回其中已注册且允许通过邮箱被发现的用户，按上传数量限流",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "通讯录发现",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "邮箱哈希列表",
+                        "name": "contact_discover",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ContactDiscover"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/discover/salt": {
+            "get": {
+                "description": "客户端使用该盐值对通讯录中的邮箱（去除首尾空白并转为小写）计算 SHA-256 哈希后再上传，服务端未配置盐值时通讯录发现不可用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "获取通讯录发现的盐值",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/list": {
+            "get": {
+                "description": "获取好友列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "获取好友列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/question": {
+            "post": {
+                "description": "对方要求回答问题才能添加好友时，返回需要回答的问题",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "获取好友验证问题",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "好友信息",
+                        "name": "friend_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/suggest": {
+            "get": {
+                "description": "根据共同好友数量推荐可能认识的人",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "获取可能认识的人",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/friend/sync": {
+            "post": {
+                "description": "根据客户端的通讯录版本号返回之后的变更，版本号为0时返回完整列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "好友"
+                ],
+                "summary": "增量同步好友列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "通讯录版本",
+                        "name": "friend_sync",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.FriendSync"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invite/create": {
+            "post": {
+                "description": "生成带签名与有效期的“加我好友”令牌，并以 base64 编码的 PNG 二维码返回",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "邀请"
+                ],
+                "summary": "生成邀请二维码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "邀请类型",
+                        "name": "invite_create",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.InviteCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/invite/redeem": {
+            "post": {
+                "description": "校验扫码得到的令牌签名与有效期，并发起好友申请",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "邀请"
+                ],
+                "summary": "使用邀请二维码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer Token令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "邀请令牌",
+                        "name": "invite_redeem",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.InviteRedeem"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "失败",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/callback": {
+            "post": {
+                "description": "提交提供方回调中的 state 与授权码，首次登录时创建账号（提供方允许时按邮箱关联已有账号），开启了两步验证时返回登录挑战",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "完成第三方登录",
+                "parameters": [
+                    {
+                        "description": "state与授权码",
+                        "name": "oauth_callback",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.OAuthCallback"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/oauth/{provider}/authorize": {
+            "get": {
+                "description": "返回 OpenID Connect 提供方的授权地址（授权码模式 + PKCE），客户端跳转到该地址完成授权",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "开始第三方登录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "身份提供方名称",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "device",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "返回结果",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "request.AccountDelete": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.AccountRestore": {
+            "type": "object",
+            "required": [
+                "checkCode",
+                "checkCodeKey",
+                "email",
+                "password"
+            ],
+            "properties": {
+                "checkCode": {
+                    "type": "string"
+                },
+                "checkCodeKey": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.AdminLoginHistory": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "pageSize": {
+                    "type": "integer",
+                    "maximum": 50,
+                    "minimum": 1
+                },
+                "suspicious": {
+                    "type": "boolean"
+                },
+                "userId": {
+                    "type": "string",
+                    "maxLength": 150
+                }
+            }
+        },
+        "request.ApiKeyCreate": {
+            "type": "object",
+            "required": [
+                "botId",
+                "scopes"
+            ],
+            "properties": {
+                "botId": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "request.ApiKeyTarget": {
+            "type": "object",
+            "required": [
+                "keyId"
+            ],
+            "properties": {
+                "keyId": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "request.BotCreate": {
+            "type": "object",
+            "required": [
+                "userName"
+            ],
+            "properties": {
+                "nickname": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "userName": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 8
+                }
+            }
+        },
+        "request.ContactDiscover": {
+            "type": "object",
+            "required": [
+                "hashes"
+            ],
+            "properties": {
+                "hashes": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "request.EmailResend": {
+            "type": "object",
+            "required": [
+                "checkCode",
+                "checkCodeKey",
+                "email"
+            ],
+            "properties": {
+                "checkCode": {
+                    "type": "string"
+                },
+                "checkCodeKey": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.EmailVerify": {
+            "type": "object",
+            "required": [
+                "code",
+                "email"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FileDelete": {
+            "type": "object",
+            "required": [
+                "fileName",
+                "md5",
+                "sha1"
+            ],
+            "properties": {
+                "fileName": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "sha1": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FileDeletes": {
+            "type": "object",
+            "required": [
+                "deletes"
+            ],
+            "properties": {
+                "deletes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/request.FileDelete"
+                    }
+                }
+            }
+        },
+        "request.FileDownload": {
+            "type": "object",
+            "required": [
+                "fileName",
+                "md5",
+                "sha1"
+            ],
+            "properties": {
+                "fileName": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "sha1": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FileList": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "type": "string",
+                    "maxLength": 512
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 150
+                },
+                "order": {
+                    "type": "string",
+                    "enum": [
+                        "asc",
+                        "desc"
+                    ]
+                },
+                "sort": {
+                    "type": "string",
+                    "enum": [
+                        "date",
+                        "name",
+                        "size"
+                    ]
+                },
+                "status": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "image",
+                        "video",
+                        "audio",
+                        "file"
+                    ]
+                }
+            }
+        },
+        "request.FileMerge": {
+            "type": "object",
+            "required": [
+                "fileName",
+                "md5",
+                "sha1"
+            ],
+            "properties": {
+                "fileName": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "sha1": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FileRecovery": {
+            "type": "object",
+            "required": [
+                "fileName",
+                "md5",
+                "sha1"
+            ],
+            "properties": {
+                "fileName": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "sha1": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FileRecoveryList": {
+            "type": "object",
+            "required": [
+                "recoveries"
+            ],
+            "properties": {
+                "recoveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/request.FileRecovery"
+                    }
+                }
+            }
+        },
+        "request.FileUpload": {
+            "type": "object",
+            "required": [
+                "chunkNumber",
+                "chunkSize",
+                "fileName",
+                "md5",
+                "sha1"
+            ],
+            "properties": {
+                "chunkNumber": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "chunkSize": {
+                    "type": "integer"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "md5": {
+                    "type": "string"
+                },
+                "sha1": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FriendRequest": {
+            "type": "object",
+            "required": [
+                "friendInfo"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "maxLength": 150
+                },
+                "friendInfo": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.FriendSync": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "request.InviteCreate": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "integer",
+                    "enum": [
+                        0
+                    ]
+                }
+            }
+        },
+        "request.InviteRedeem": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "maxLength": 150
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.Login": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "checkCode": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "checkCodeKey": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "device": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "deviceId": {
+                    "type": "string",
+                    "maxLength": 128
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 128
+                }
+            }
+        },
+        "request.LoginHistory": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "pageSize": {
+                    "type": "integer",
+                    "maximum": 50,
+                    "minimum": 1
+                }
+            }
+        },
+        "request.LoginUnlock": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.OAuthCallback": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.PartInfo": {
+            "type": "object",
+            "required": [
+                "partNums",
+                "uploadId"
+            ],
+            "properties": {
+                "partNums": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.PasswordChange": {
+            "type": "object",
+            "required": [
+                "newPassword",
+                "oldPassword"
+            ],
+            "properties": {
+                "newPassword": {
+                    "type": "string"
+                },
+                "oldPassword": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.PasswordForgot": {
+            "type": "object",
+            "required": [
+                "checkCode",
+                "checkCodeKey",
+                "email"
+            ],
+            "properties": {
+                "checkCode": {
+                    "type": "string"
+                },
+                "checkCodeKey": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.PasswordReset": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.Privacy": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string",
+                    "maxLength": 150
+                },
+                "friendPolicy": {
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "lastSeenVisibility": {
+                    "type": "integer",
+                    "maximum": 2,
+                    "minimum": 0
+                },
+                "question": {
+                    "type": "string",
+                    "maxLength": 150
+                },
+                "searchByEmail": {
+                    "type": "boolean"
+                },
+                "searchByUsername": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "request.ProfileUpdate": {
+            "type": "object",
+            "properties": {
+                "nickname": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 8
+                }
+            }
+        },
+        "request.RefreshToken": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.Register": {
+            "type": "object",
+            "required": [
+                "checkCode",
+                "checkCodeKey",
+                "email",
+                "password",
+                "userName"
+            ],
+            "properties": {
+                "checkCode": {
+                    "type": "string"
+                },
+                "checkCodeKey": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "userName": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 8
+                }
+            }
+        },
+        "request.RoleAssign": {
+            "type": "object",
+            "required": [
+                "role",
+                "userId"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.RoleSave": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "permissions": {
+                    "type": "array",
+                    "maxItems": 64,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "request.SessionRevoke": {
+            "type": "object",
+            "required": [
+                "sessionId"
+            ],
+            "properties": {
+                "sessionId": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.TwoFactorCode": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.TwoFactorDisable": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.TwoFactorLogin": {
+            "type": "object",
+            "required": [
+                "challenge",
+                "code"
+            ],
+            "properties": {
+                "challenge": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "request.UserBan": {
+            "type": "object",
+            "required": [
+                "reason",
+                "userId"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "until": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.UserSearch": {
+            "type": "object",
+            "required": [
+                "userInfo"
+            ],
+            "properties": {
+                "page": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "pageSize": {
+                    "type": "integer",
+                    "maximum": 50,
+                    "minimum": 1
+                },
+                "userInfo": {
+                    "type": "string",
+                    "maxLength": 80
+                }
+            }
+        },
+        "request.UserUnban": {
+            "type": "object",
+            "required": [
+                "userId"
+            ],
+            "properties": {
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {},
+                "msg": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.AccountDeletion": {
+            "type": "object",
+            "properties": {
+                "purgeAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ApiKeyInfo": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
                     "type": "string"
                 },
-                "md5": {
+                "keyId": {
                     "type": "string"
                 },
-                "sha1": {
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "lastUsedIp": {
                     "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "request.FileDeletes": {
+        "types.ApiKeySecret": {
             "type": "object",
-            "required": [
-                "deletes"
-            ],
             "properties": {
-                "deletes": {
+                "key": {
+                    "type": "string"
+                },
+                "keyId": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BotInfo": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "keys": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/request.FileDelete"
+                        "$ref": "#/definitions/types.ApiKeyInfo"
                     }
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
                 }
             }
         },
-        "request.FileDownload": {
+        "types.CaptDateBase64": {
             "type": "object",
-            "required": [
-                "fileName",
-                "md5",
-                "sha1"
-            ],
             "properties": {
-                "fileName": {
+                "b64s": {
                     "type": "string"
                 },
-                "md5": {
+                "challenge": {
                     "type": "string"
                 },
-                "sha1": {
+                "difficulty": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "request.FileMerge": {
+        "types.ContactChange": {
             "type": "object",
-            "required": [
-                "fileName",
-                "md5",
-                "sha1"
-            ],
             "properties": {
-                "fileName": {
+                "friend": {
+                    "$ref": "#/definitions/types.Friend"
+                },
+                "op": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.DiscoveredContact": {
+            "type": "object",
+            "properties": {
+                "avatar": {
                     "type": "string"
                 },
-                "md5": {
+                "hash": {
                     "type": "string"
                 },
-                "sha1": {
+                "lastSeen": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "request.FileRecovery": {
+        "types.FileInfo": {
             "type": "object",
-            "required": [
-                "fileName",
-                "md5",
-                "sha1"
-            ],
             "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
                 "fileName": {
                     "type": "string"
                 },
+                "fileType": {
+                    "type": "string"
+                },
                 "md5": {
                     "type": "string"
                 },
                 "sha1": {
                     "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "request.FileRecoveryList": {
+        "types.FileList": {
             "type": "object",
-            "required": [
-                "recoveries"
-            ],
             "properties": {
-                "recoveries": {
+                "files": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/request.FileRecovery"
+                        "$ref": "#/definitions/types.FileInfo"
                     }
+                },
+                "nextCursor": {
+                    "type": "string"
                 }
             }
         },
-        "request.FileUpload": {
+        "types.Friend": {
             "type": "object",
-            "required": [
-                "chunkNumber",
-                "chunkSize",
-                "fileName",
-                "md5",
-                "sha1"
-            ],
             "properties": {
-                "chunkNumber": {
-                    "type": "integer",
-                    "minimum": 1
+                "avatar": {
+                    "type": "string"
                 },
-                "chunkSize": {
+                "lastSeen": {
+                    "type": "string"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "integer"
                 },
-                "fileName": {
+                "username": {
                     "type": "string"
                 },
-                "md5": {
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.FriendSuggestion": {
+            "type": "object",
+            "properties": {
+                "avatar": {
                     "type": "string"
                 },
-                "sha1": {
+                "lastSeen": {
+                    "type": "string"
+                },
+                "mutual": {
+                    "type": "integer"
+                },
+                "nickname": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "uuid": {
                     "type": "string"
                 }
             }
         },
-        "request.FriendRequest": {
+        "types.FriendSync": {
             "type": "object",
-            "required": [
-                "friendInfo"
-            ],
             "properties": {
-                "friendInfo": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ContactChange"
+                    }
+                },
+                "friends": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Friend"
+                    }
+                },
+                "full": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.InviteQRCode": {
+            "type": "object",
+            "properties": {
+                "b64s": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "request.Login": {
+        "types.JWK": {
             "type": "object",
-            "required": [
-                "checkCode",
-                "checkCodeKey",
-                "email",
-                "password"
-            ],
             "properties": {
-                "checkCode": {
+                "alg": {
                     "type": "string"
                 },
-                "checkCodeKey": {
+                "crv": {
                     "type": "string"
                 },
-                "email": {
+                "e": {
                     "type": "string"
                 },
-                "password": {
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
                     "type": "string"
                 }
             }
         },
-        "request.PartInfo": {
+        "types.JWKS": {
             "type": "object",
-            "required": [
-                "partNums",
-                "uploadId"
-            ],
             "properties": {
-                "partNums": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.JWK"
+                    }
+                }
+            }
+        },
+        "types.LoginResult": {
+            "type": "object",
+            "properties": {
+                "challenge": {
                     "type": "string"
                 },
-                "uploadId": {
+                "tokens": {
+                    "$ref": "#/definitions/types.TokenPair"
+                },
+                "twoFactorRequired": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.OAuthAuthorize": {
+            "type": "object",
+            "properties": {
+                "authUrl": {
+                    "type": "string"
+                },
+                "state": {
                     "type": "string"
                 }
             }
         },
-        "request.Register": {
+        "types.Privacy": {
             "type": "object",
-            "required": [
-                "checkCode",
-                "checkCodeKey",
-                "email",
-                "password",
-                "userName"
-            ],
             "properties": {
-                "checkCode": {
+                "friendPolicy": {
+                    "type": "integer"
+                },
+                "lastSeenVisibility": {
+                    "type": "integer"
+                },
+                "question": {
                     "type": "string"
                 },
-                "checkCodeKey": {
+                "searchByEmail": {
+                    "type": "boolean"
+                },
+                "searchByUsername": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "types.RoleInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
                     "type": "string"
                 },
-                "email": {
+                "name": {
                     "type": "string"
                 },
-                "password": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.SessionInfo": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
                     "type": "string"
                 },
-                "userName": {
-                    "type": "string",
-                    "maxLength": 32,
-                    "minLength": 8
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastSeen": {
+                    "type": "string"
+                },
+                "sessionId": {
+                    "type": "string"
+                },
+                "userAgent": {
+                    "type": "string"
                 }
             }
         },
-        "request.UserSearch": {
+        "types.TokenPair": {
             "type": "object",
-            "required": [
-                "userInfo"
-            ],
             "properties": {
-                "userInfo": {
+                "accessToken": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "refreshToken": {
+                    "type": "string"
+                },
+                "sessionId": {
                     "type": "string"
                 }
             }
         },
-        "response.Response": {
+        "types.TwoFactorSetup": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "b64s": {
+                    "type": "string"
                 },
-                "data": {},
-                "msg": {
+                "secret": {
+                    "type": "string"
+                },
+                "uri": {
                     "type": "string"
                 }
             }
@@ -0,0 +1,274 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"time"
+)
+
+type AccountDeleteService interface {
+	DeleteAccount(ctx context.Context, claims *types.GIClaims, remove request.AccountDelete) (*types.AccountDeletion, error)
+	RestoreAccount(ctx *gin.Context, restore request.AccountRestore) error
+	PurgeDeletedAccounts(ctx context.Context) error
+}
+
+// DeleteAccount 注销当前账号
+// 账号立即停用：用户记录被软删除，所有会话被注销，搜索、通讯录发现与邀请都不再能找到该用户。
+// 宽限期内可以通过 RestoreAccount 恢复，宽限期过后由定时任务彻底清理数据。
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	remove request.AccountDelete: 密码，开启两步验证时还需要动态验证码或恢复码
+//
+// 返回值:
+//
+//	*types.AccountDeletion: 数据将被彻底清理的时间
+//	error: 密码或动态验证码错误、数据库操作失败时返回的错误
+func (s *service) DeleteAccount(ctx context.Context, claims *types.GIClaims, remove request.AccountDelete) (*types.AccountDeletion, error) {
+	purgeAt := time.Now().Add(defines.ACCOUNT_DELETE_GRACE * time.Second)
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		if !utils.CompareHashPassword(user.Password, remove.Password) {
+			return exception.ErrPassword
+		}
+		if s.twoFactorEnabled(ctx, claims.UserId) {
+			if err := s.verifySecondFactor(ctx, claims.UserId, remove.Code); err != nil {
+				return err
+			}
+		}
+		if err := s.GetDB(ctx).Model(&user).Updates(map[string]interface{}{"purge_at": purgeAt, "status": enums.LogOut}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("注销账号失败")
+			return err
+		}
+		if err := s.GetDB(ctx).Delete(&user).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("注销账号失败")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.revokeSessions(ctx, claims.UserId, ""); err != nil {
+		log.Logger.Error().Err(err).Msg("注销账号的会话失败")
+		return nil, err
+	}
+	return &types.AccountDeletion{PurgeAt: purgeAt}, nil
+}
+
+// RestoreAccount 在宽限期内使用邮箱与密码恢复已注销的账号，恢复后需要重新登录
+// 与登录共用失败计数与锁定，避免被用来猜测密码
+func (s *service) RestoreAccount(ctx *gin.Context, restore request.AccountRestore) error {
+	ip := ctx.ClientIP()
+	if err := s.CheckLoginLock(ctx, ip, restore.Email); err != nil {
+		return err
+	}
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		user, ok := s.findDeletedAccount(ctx, restore.Email, restore.Password)
+		if !ok {
+			s.RecordLoginFailure(ctx, ip, restore.Email)
+			return exception.ErrLoginFailed
+		}
+		if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"deleted_at": nil, "purge_at": nil}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("恢复账号失败")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.clearLoginFailure(ctx, restore.Email)
+	return nil
+}
+
+// PurgeDeletedAccounts 彻底清理宽限期已过的注销账号，每个账号的清理结果记录为一条清理报告
+// 清理失败的账号保留注销状态，在下一次定时任务中重试
+func (s *service) PurgeDeletedAccounts(ctx context.Context) error {
+	var lastId uint
+	for {
+		var users []model.User
+		if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).
+			Where("id > ? AND deleted_at IS NOT NULL AND purge_at IS NOT NULL AND purge_at <= ?", lastId, time.Now()).
+			Order("id").
+			Limit(defines.ACCOUNT_PURGE_BATCH).
+			Find(&users).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询待清理账号失败")
+			return err
+		}
+		for i := range users {
+			if err := s.GetDB(ctx).Create(s.purgeAccount(ctx, &users[i])).Error; err != nil {
+				log.Logger.Error().Err(err).Msg("保存清理报告失败")
+			}
+			lastId = users[i].ID
+		}
+		if len(users) < defines.ACCOUNT_PURGE_BATCH {
+			return nil
+		}
+	}
+}
+
+// purgeAccount 删除账号的好友关系、通讯录记录、隐私与两步验证设置、机器人与 API 密钥、文件记录与用户记录，
+// 再删除没有其他用户引用的存储对象以及该用户在 Valkey 中的数据
+// 项目目前没有持久化的消息记录，消息投递只依赖会话，会话注销后不再有需要清理的消息数据
+func (s *service) purgeAccount(ctx context.Context, user *model.User) *model.PurgeReport {
+	report := &model.PurgeReport{UserId: user.Uuid, PurgedAt: time.Now()}
+	var errs []string
+	var files []model.File
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		// 好友的通讯录中记录一次删除，客户端增量同步时移除该联系人
+		var friendIds []string
+		if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+			Where("userid = ? AND status = ?", user.Uuid, enums.IS_FRIEND).
+			Order("friendid").
+			Pluck("friendid", &friendIds).Error; err != nil {
+			return err
+		}
+		for _, friendId := range friendIds {
+			if err := s.recordContactChange(ctx, enums.CONTACT_REMOVE, friendId, user.Uuid); err != nil {
+				return err
+			}
+		}
+		result := s.GetDB(ctx).Unscoped().Where("userid = ? OR friendid = ?", user.Uuid, user.Uuid).Delete(&model.UserFriend{})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Friendships = result.RowsAffected
+		result = s.GetDB(ctx).Unscoped().Where("userid = ?", user.Uuid).Delete(&model.ContactLog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.ContactLogs = result.RowsAffected
+		for _, value := range []interface{}{&model.ContactVersion{}, &model.UserPrivacy{}, &model.UserTotp{}, &model.RecoveryCode{}, &model.UserRole{}, &model.UserIdentity{}} {
+			if err := s.GetDB(ctx).Unscoped().Where("userid = ?", user.Uuid).Delete(value).Error; err != nil {
+				return err
+			}
+		}
+		// 登录记录中邮箱不存在时的尝试没有用户ID，按邮箱一并清理
+		if err := s.GetDB(ctx).Unscoped().Where("userid = ? OR email = ?", user.Uuid, user.Email).Delete(&model.LoginRecord{}).Error; err != nil {
+			return err
+		}
+		// 用户创建的机器人及其 API 密钥一并删除，机器人上传的文件与用户的文件一起清理
+		var botIds []string
+		if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("bot_owner = ?", user.Uuid).Pluck("uuid", &botIds).Error; err != nil {
+			return err
+		}
+		if len(botIds) != 0 {
+			if err := s.GetDB(ctx).Unscoped().Where("botid IN ?", botIds).Delete(&model.ApiKey{}).Error; err != nil {
+				return err
+			}
+			if err := s.GetDB(ctx).Unscoped().Where("uuid IN ?", botIds).Delete(&model.User{}).Error; err != nil {
+				return err
+			}
+		}
+		owners := append([]string{user.Uuid}, botIds...)
+		if err := s.GetDB(ctx).Unscoped().Where("owner IN ?", owners).Find(&files).Error; err != nil {
+			return err
+		}
+		result = s.GetDB(ctx).Unscoped().Where("owner IN ?", owners).Delete(&model.File{})
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Files = result.RowsAffected
+		return s.GetDB(ctx).Unscoped().Delete(&model.User{}, user.ID).Error
+	})
+	if err != nil {
+		log.Logger.Error().Err(err).Str("userId", user.Uuid).Msg("清理账号数据失败")
+		report.Errors = err.Error()
+		return report
+	}
+	// 相同内容的文件在存储中只保存一份，只有没有其他用户（包括回收站中）引用的对象才会被删除
+	var objectNames []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if seen[file.ObjectName] {
+			continue
+		}
+		seen[file.ObjectName] = true
+		var count int64
+		if err := s.GetDB(ctx).Unscoped().Model(&model.File{}).Where("objectname = ?", file.ObjectName).Count(&count).Error; err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		if file.Status == int8(enums.FILEUPLOADING) && file.UploadId != "" {
+			_ = s.minClient.AbortUpload(ctx, file.ObjectName, file.UploadId)
+			_ = s.DelValue(ctx, defines.UPLOAD_ID+file.ObjectName, defines.COMPLETED_PARTS+file.UploadId)
+			continue
+		}
+		objectNames = append(objectNames, file.ObjectName)
+	}
+	if strings.HasPrefix(user.Avatar, defines.AVATAR_PREFIX) {
+		objectNames = append(objectNames, user.Avatar)
+	}
+	if len(objectNames) > 0 {
+		if err := s.minClient.DeleteFiles(ctx, true, objectNames...); err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			report.Objects = int64(len(objectNames))
+		}
+	}
+	sessions := s.GetSetMembers(ctx, defines.USER_SESSIONS+user.Uuid)
+	if err := s.revokeSessions(ctx, user.Uuid, ""); err != nil {
+		errs = append(errs, err.Error())
+	}
+	keys := []string{
+		defines.USER_SESSIONS + user.Uuid,
+		defines.USER_BANNED + user.Uuid,
+		defines.USER_PERMISSIONS + user.Uuid,
+		defines.FRIEND_SUGGEST + user.Uuid,
+		defines.CONTACT_DISCOVER + user.Uuid,
+		defines.EMAIL_VERIFY + user.Uuid,
+		defines.EMAIL_VERIFY_FAIL + user.Uuid,
+		defines.EMAIL_VERIFY_COOLDOWN + user.Uuid,
+		defines.PASSWORD_RESET + user.Uuid,
+		defines.PASSWORD_RESET_SENT + user.Uuid,
+		defines.LOGIN_FAIL + accountScope(user.Email),
+		defines.LOGIN_LOCK + accountScope(user.Email),
+		defines.LOGIN_LOCK_LEVEL + accountScope(user.Email),
+		defines.LOGIN_LOCK_SEEN + accountScope(user.Email),
+	}
+	if err := s.DelValue(ctx, keys...); err != nil {
+		errs = append(errs, err.Error())
+	} else {
+		report.ValkeyKeys = int64(len(keys) + len(sessions))
+	}
+	report.Errors = strings.Join(errs, "; ")
+	log.Logger.Info().Str("userId", user.Uuid).Int64("files", report.Files).Int64("objects", report.Objects).Msg("注销账号数据已清理")
+	return report
+}
+
+// findDeletedAccount 查找宽限期内、密码匹配的已注销账号
+func (s *service) findDeletedAccount(ctx context.Context, email, password string) (*model.User, bool) {
+	var user model.User
+	if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).
+		Where("email = ? AND deleted_at IS NOT NULL AND purge_at > ?", email, time.Now()).
+		First(&user).Error; err != nil {
+		return nil, false
+	}
+	if !utils.CompareHashPassword(user.Password, password) {
+		return nil, false
+	}
+	return &user, true
+}
+
+// deletionError 根据用户记录生成账号已注销的错误
+func deletionError(user *model.User) error {
+	return exception.ErrAccountDeleted.WithData(types.AccountDeletion{PurgeAt: *user.PurgeAt})
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+type BanService interface {
+	BanUser(ctx context.Context, claims *types.GIClaims, ban request.UserBan) error
+	UnbanUser(ctx context.Context, claims *types.GIClaims, unban request.UserUnban) error
+	CheckBanned(ctx context.Context, userId string) error
+}
+
+// BanUser 管理员封禁用户，截止时间为空时永久封禁
+// 封禁信息同时写入 Valkey 供每次请求快速检查，并立即注销该用户的所有会话
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 管理员的令牌声明
+//	ban request.UserBan: 被封禁的用户ID、封禁原因与截止时间
+//
+// 返回值:
+//
+//	error: 封禁自己、用户不存在或截止时间早于当前时间时返回的错误
+func (s *service) BanUser(ctx context.Context, claims *types.GIClaims, ban request.UserBan) error {
+	if ban.UserId == claims.UserId {
+		return exception.ErrBadRequest
+	}
+	var ttl int64
+	if ban.Until != nil {
+		ttl = int64(time.Until(*ban.Until).Seconds())
+		if ttl <= 0 {
+			return exception.ErrBadRequest
+		}
+	}
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		result := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", ban.UserId).Updates(map[string]interface{}{
+			"status":       enums.Forbid,
+			"ban_reason":   ban.Reason,
+			"banned_until": ban.Until,
+		})
+		if result.Error != nil {
+			log.Logger.Error().Err(result.Error).Msg("封禁用户失败")
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return exception.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(types.BanInfo{Reason: ban.Reason, Until: ban.Until})
+	if err != nil {
+		return err
+	}
+	var cacheErr error
+	if ttl > 0 {
+		cacheErr = s.SetAndTime(ctx, defines.USER_BANNED+ban.UserId, string(data), ttl)
+	} else {
+		cacheErr = s.SetValue(ctx, defines.USER_BANNED+ban.UserId, string(data))
+	}
+	// 封禁已经写入数据库，缓存写入失败时仍然要注销会话，不能让已有的会话继续有效
+	if cacheErr != nil {
+		log.Logger.Error().Err(cacheErr).Str("userId", ban.UserId).Msg("记录封禁信息失败")
+	}
+	// 用户创建的机器人没有会话，它们的 API 密钥在 AuthenticateApiKey 中检查所有者的封禁状态，解除封禁后自动恢复
+	if err := s.revokeSessions(ctx, ban.UserId, ""); err != nil {
+		log.Logger.Error().Err(err).Msg("注销被封禁用户的会话失败")
+		return err
+	}
+	if cacheErr != nil {
+		return cacheErr
+	}
+	log.Logger.Info().Str("admin", claims.UserId).Str("userId", ban.UserId).Str("reason", ban.Reason).Msg("封禁用户")
+	return nil
+}
+
+// UnbanUser 管理员提前解除用户的封禁
+func (s *service) UnbanUser(ctx context.Context, claims *types.GIClaims, unban request.UserUnban) error {
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ? AND status = ?", unban.UserId, enums.Forbid).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		return s.liftBan(ctx, user.Uuid)
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger.Info().Str("admin", claims.UserId).Str("userId", unban.UserId).Msg("解除封禁")
+	return nil
+}
+
+// CheckBanned 检查用户是否处于封禁状态，封禁时返回携带原因与截止时间的错误
+// 只读取 Valkey 中的封禁信息，可以在每次请求或每条消息投递前调用
+func (s *service) CheckBanned(ctx context.Context, userId string) error {
+	value := s.GetValue(ctx, defines.USER_BANNED+userId)
+	if value == "" {
+		return nil
+	}
+	var info types.BanInfo
+	if err := json.Unmarshal([]byte(value), &info); err != nil {
+		log.Logger.Error().Err(err).Msg("封禁信息解析失败")
+	}
+	return exception.ErrAccountBanned.WithData(info)
+}
+
+// checkBan 登录时检查封禁状态，封禁已到期时解除封禁，未到期则返回封禁原因与截止时间
+func (s *service) checkBan(ctx context.Context, user *model.User) error {
+	if user.Status != int8(enums.Forbid) {
+		return nil
+	}
+	if user.BannedUntil == nil || user.BannedUntil.After(time.Now()) {
+		return banError(user)
+	}
+	return s.liftBan(ctx, user.Uuid)
+}
+
+// liftBan 解除封禁并清除封禁信息，需在事务中调用
+func (s *service) liftBan(ctx context.Context, userId string) error {
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", userId).Updates(map[string]interface{}{
+		"status":       enums.LogOut,
+		"ban_reason":   "",
+		"banned_until": nil,
+	}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("解除封禁失败")
+		return err
+	}
+	return s.DelValue(ctx, defines.USER_BANNED+userId)
+}
+
+// banError 根据用户记录生成封禁错误
+func banError(user *model.User) error {
+	return exception.ErrAccountBanned.WithData(types.BanInfo{Reason: user.BanReason, Until: user.BannedUntil})
+}
@@ -0,0 +1,323 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BotService interface {
+	CreateBot(ctx context.Context, claims *types.GIClaims, create request.BotCreate) (*types.BotInfo, error)
+	ListBots(ctx context.Context, claims *types.GIClaims) ([]types.BotInfo, error)
+	CreateApiKey(ctx context.Context, claims *types.GIClaims, create request.ApiKeyCreate) (*types.ApiKeySecret, error)
+	RotateApiKey(ctx context.Context, claims *types.GIClaims, target request.ApiKeyTarget) (*types.ApiKeySecret, error)
+	RevokeApiKey(ctx context.Context, claims *types.GIClaims, target request.ApiKeyTarget) error
+	AuthenticateApiKey(ctx context.Context, key, scope, ip string) (*types.Principal, error)
+}
+
+// CreateBot 创建一个属于当前用户的机器人账号
+// 机器人没有可用的密码与邮箱，只能通过 API 密钥访问声明了权限范围的接口
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	create request.BotCreate: 机器人的用户名与昵称
+//
+// 返回值:
+//
+//	*types.BotInfo: 新创建的机器人
+//	error: 用户名已存在或数据库操作失败时返回的错误
+func (s *service) CreateBot(ctx context.Context, claims *types.GIClaims, create request.BotCreate) (*types.BotInfo, error) {
+	password, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.New().String()
+	bot := model.User{
+		Uuid:          id,
+		Username:      create.UserName,
+		Nickname:      create.Nickname,
+		Password:      password,
+		Email:         id + defines.BOT_EMAIL_DOMAIN,
+		EmailVerified: true,
+		BotOwner:      claims.UserId,
+	}
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		var count int64
+		if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("username = ?", create.UserName).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != 0 {
+			return exception.ErrAlreadyExist
+		}
+		return s.GetDB(ctx).Create(&bot).Error
+	})
+	if err != nil {
+		if !errors.Is(err, exception.ErrAlreadyExist) {
+			log.Logger.Error().Err(err).Msg("创建机器人失败")
+		}
+		return nil, err
+	}
+	return &types.BotInfo{Uuid: bot.Uuid, Username: bot.Username, Nickname: bot.Nickname, CreatedAt: bot.CreatedAt, Keys: make([]types.ApiKeyInfo, 0)}, nil
+}
+
+// ListBots 列出当前用户创建的机器人及其 API 密钥，不包含密钥明文
+func (s *service) ListBots(ctx context.Context, claims *types.GIClaims) ([]types.BotInfo, error) {
+	var bots []model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("bot_owner = ?", claims.UserId).Order("id").Find(&bots).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询机器人失败")
+		return nil, err
+	}
+	infos := make([]types.BotInfo, 0, len(bots))
+	if len(bots) == 0 {
+		return infos, nil
+	}
+	botIds := make([]string, 0, len(bots))
+	for _, bot := range bots {
+		botIds = append(botIds, bot.Uuid)
+	}
+	var keys []model.ApiKey
+	if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("botid IN ?", botIds).Order("id").Find(&keys).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询API密钥失败")
+		return nil, err
+	}
+	for _, bot := range bots {
+		info := types.BotInfo{Uuid: bot.Uuid, Username: bot.Username, Nickname: bot.Nickname, CreatedAt: bot.CreatedAt, Keys: make([]types.ApiKeyInfo, 0)}
+		for _, key := range keys {
+			if key.BotId == bot.Uuid {
+				info.Keys = append(info.Keys, types.ApiKeyInfo{
+					KeyId:      key.KeyId,
+					Name:       key.Name,
+					Scopes:     strings.Split(key.Scopes, ","),
+					CreatedAt:  key.CreatedAt,
+					LastUsedAt: key.LastUsedAt,
+					LastUsedIp: key.LastUsedIp,
+				})
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// CreateApiKey 为当前用户的机器人创建 API 密钥，每个机器人最多 API_KEY_PER_BOT 个
+// 密钥格式为 gim_<密钥ID>_<随机串>，只保存随机串的哈希，明文只在本次返回
+func (s *service) CreateApiKey(ctx context.Context, claims *types.GIClaims, create request.ApiKeyCreate) (*types.ApiKeySecret, error) {
+	var result *types.ApiKeySecret
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.ownedBot(ctx, claims, create.BotId); err != nil {
+			return err
+		}
+		var count int64
+		if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("botid = ?", create.BotId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= defines.API_KEY_PER_BOT {
+			return exception.ErrTooManyRequests
+		}
+		keyId, secret, hash, err := newApiKey()
+		if err != nil {
+			return err
+		}
+		if err := s.GetDB(ctx).Create(&model.ApiKey{
+			BotId:   create.BotId,
+			KeyId:   keyId,
+			KeyHash: hash,
+			Name:    create.Name,
+			Scopes:  strings.Join(uniqueStrings(create.Scopes), ","),
+		}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("创建API密钥失败")
+			return err
+		}
+		result = &types.ApiKeySecret{KeyId: keyId, Key: formatApiKey(keyId, secret)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Logger.Info().Str("userId", claims.UserId).Str("botId", create.BotId).Str("keyId", result.KeyId).Msg("创建API密钥")
+	return result, nil
+}
+
+// RotateApiKey 轮换 API 密钥，密钥ID与权限范围不变，旧的密钥立即失效
+func (s *service) RotateApiKey(ctx context.Context, claims *types.GIClaims, target request.ApiKeyTarget) (*types.ApiKeySecret, error) {
+	var result *types.ApiKeySecret
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		key, err := s.ownedApiKey(ctx, claims, target.KeyId)
+		if err != nil {
+			return err
+		}
+		secret, err := newSecureToken()
+		if err != nil {
+			return err
+		}
+		if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("id = ?", key.ID).Update("key_hash", hashToken(secret)).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("轮换API密钥失败")
+			return err
+		}
+		result = &types.ApiKeySecret{KeyId: key.KeyId, Key: formatApiKey(key.KeyId, secret)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Logger.Info().Str("userId", claims.UserId).Str("keyId", target.KeyId).Msg("轮换API密钥")
+	return result, nil
+}
+
+// RevokeApiKey 吊销 API 密钥
+func (s *service) RevokeApiKey(ctx context.Context, claims *types.GIClaims, target request.ApiKeyTarget) error {
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		key, err := s.ownedApiKey(ctx, claims, target.KeyId)
+		if err != nil {
+			return err
+		}
+		return s.GetDB(ctx).Delete(&model.ApiKey{}, key.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger.Info().Str("userId", claims.UserId).Str("keyId", target.KeyId).Msg("吊销API密钥")
+	return nil
+}
+
+// AuthenticateApiKey 校验 API 密钥并返回机器人的身份
+// 同一 IP 在 API_KEY_RATE_WINDOW 秒内最多校验失败 API_KEY_FAIL_LIMIT 次，计数在查询数据库之前检查；
+// 密钥校验通过后才计入该密钥的配额，每个密钥在窗口内最多使用 API_KEY_RATE_LIMIT 次，无效的请求不会耗尽他人密钥的配额。
+// 机器人或其所有者被封禁、所有者已注销时密钥不可用；密钥没有路由所需的权限范围时返回 ErrPermissionDenied。
+func (s *service) AuthenticateApiKey(ctx context.Context, key, scope, ip string) (*types.Principal, error) {
+	if count, err := strconv.ParseInt(s.GetValue(ctx, defines.API_KEY_FAIL+ip), 10, 64); err == nil && count >= defines.API_KEY_FAIL_LIMIT {
+		return nil, exception.ErrTooManyRequests
+	}
+	apiKey, bot, err := s.verifyApiKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, exception.ErrInvalidApiKey) {
+			if _, err := s.IncrAndTime(ctx, defines.API_KEY_FAIL+ip, 1, defines.API_KEY_RATE_WINDOW); err != nil {
+				log.Logger.Error().Err(err).Msg("API密钥失败计数失败")
+			}
+		}
+		return nil, err
+	}
+	count, err := s.IncrAndTime(ctx, defines.API_KEY_RATE+apiKey.KeyId, 1, defines.API_KEY_RATE_WINDOW)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("API密钥限流计数失败")
+		return nil, err
+	}
+	if count > defines.API_KEY_RATE_LIMIT {
+		return nil, exception.ErrTooManyRequests
+	}
+	if err := s.CheckBanned(ctx, bot.Uuid); err != nil {
+		return nil, err
+	}
+	if err := s.CheckBanned(ctx, bot.BotOwner); err != nil {
+		return nil, err
+	}
+	scopes := strings.Split(apiKey.Scopes, ",")
+	if !slices.Contains(scopes, scope) {
+		return nil, exception.ErrPermissionDenied
+	}
+	// 最后使用时间只在间隔超过 API_KEY_TOUCH_INTERVAL 秒时更新，避免每次请求都写数据库
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > defines.API_KEY_TOUCH_INTERVAL*time.Second || apiKey.LastUsedIp != ip {
+		if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("id = ?", apiKey.ID).UpdateColumns(map[string]interface{}{
+			"last_used_at": now,
+			"last_used_ip": ip,
+		}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("记录API密钥使用时间失败")
+		}
+	}
+	return &types.Principal{
+		Claims:      &types.GIClaims{UserId: bot.Uuid},
+		Permissions: make([]string, 0),
+		Scopes:      scopes,
+	}, nil
+}
+
+// verifyApiKey 解析密钥并校验随机串的哈希，返回密钥与所属的机器人
+// 机器人的所有者已注销（包括宽限期内）时同样视为无效的密钥
+func (s *service) verifyApiKey(ctx context.Context, key string) (*model.ApiKey, *model.User, error) {
+	rest, ok := strings.CutPrefix(key, defines.API_KEY_PREFIX)
+	if !ok {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	keyId, secret, ok := strings.Cut(rest, "_")
+	if !ok || keyId == "" || len(keyId) > 32 || secret == "" {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	var apiKey model.ApiKey
+	if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("keyid = ?", keyId).First(&apiKey).Error; err != nil {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(apiKey.KeyHash)) != 1 {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	var bot model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ? AND bot_owner <> ''", apiKey.BotId).First(&bot).Error; err != nil {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	var owners int64
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", bot.BotOwner).Count(&owners).Error; err != nil {
+		return nil, nil, err
+	}
+	if owners == 0 {
+		return nil, nil, exception.ErrInvalidApiKey
+	}
+	return &apiKey, &bot, nil
+}
+
+// ownedBot 检查机器人是否属于当前用户
+func (s *service) ownedBot(ctx context.Context, claims *types.GIClaims, botId string) error {
+	var count int64
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ? AND bot_owner = ?", botId, claims.UserId).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return exception.ErrNotFound
+	}
+	return nil
+}
+
+// ownedApiKey 查找属于当前用户的机器人的密钥
+func (s *service) ownedApiKey(ctx context.Context, claims *types.GIClaims, keyId string) (*model.ApiKey, error) {
+	var key model.ApiKey
+	if err := s.GetDB(ctx).Model(&model.ApiKey{}).Where("keyid = ?", keyId).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, exception.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := s.ownedBot(ctx, claims, key.BotId); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// newApiKey 生成密钥ID与随机串，返回随机串的哈希用于保存
+func newApiKey() (keyId, secret, hash string, err error) {
+	buf := make([]byte, 8)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	if secret, err = newSecureToken(); err != nil {
+		return "", "", "", err
+	}
+	return hex.EncodeToString(buf), secret, hashToken(secret), nil
+}
+
+func formatApiKey(keyId, secret string) string {
+	return defines.API_KEY_PREFIX + keyId + "_" + secret
+}
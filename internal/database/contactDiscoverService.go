@@ -0,0 +1,115 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"github.com/rs/zerolog/log"
+	"strings"
+)
+
+type ContactDiscoverService interface {
+	DiscoverContacts(ctx context.Context, claims *types.GIClaims, discover request.ContactDiscover) ([]types.DiscoveredContact, error)
+	BackfillEmailHash(ctx context.Context) error
+}
+
+// DiscoverContacts 根据客户端上传的加盐邮箱哈希查找已注册且允许通过邮箱被发现的用户
+// 服务端只比较哈希值，不会得到未匹配的邮箱地址；每个用户在一个窗口期内可查询的哈希总数有限，防止枚举账号
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	discover request.ContactDiscover: 通讯录中邮箱的哈希列表
+//
+// 返回值:
+//
+//	[]types.DiscoveredContact: 匹配到的用户公开资料及对应的哈希
+//	error: 超出频率限制或查询失败时返回的错误
+func (s *service) DiscoverContacts(ctx context.Context, claims *types.GIClaims, discover request.ContactDiscover) ([]types.DiscoveredContact, error) {
+	hashes := make([]string, 0, len(discover.Hashes))
+	seen := make(map[string]bool, len(discover.Hashes))
+	for _, hash := range discover.Hashes {
+		hash = strings.ToLower(hash)
+		if !seen[hash] {
+			seen[hash] = true
+			hashes = append(hashes, hash)
+		}
+	}
+	// 按上传的哈希数量计数，而不是请求次数，拆分请求无法绕过限制
+	count, err := s.IncrAndTime(ctx, defines.CONTACT_DISCOVER+claims.UserId, int64(len(hashes)), defines.CONTACT_DISCOVER_TTL)
+	if err != nil {
+		return nil, exception.ErrTooManyRequests
+	}
+	if count > defines.CONTACT_DISCOVER_LIMIT {
+		return nil, exception.ErrTooManyRequests
+	}
+	contacts := make([]types.DiscoveredContact, 0)
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		// 拉黑了当前用户的用户不会被发现
+		blockedBy := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).
+			Select("userid").
+			Where("friendid = ? AND status = ?", claims.UserId, enums.BLACK)
+		if err := s.GetDB(ctx).Model(&model.User{}).
+			Select("user.email_hash AS hash, user.uuid, user.username, user.nickname, user.avatar, user.last_seen").
+			Joins("LEFT JOIN user_privacy ON user_privacy.userid = user.uuid AND user_privacy.deleted_at IS NULL").
+			Where("user.email_hash IN ?", hashes).
+			Where("user.uuid <> ?", claims.UserId).
+			Where("user.status <> ?", enums.Forbid).
+			Where("user.uuid NOT IN (?)", blockedBy).
+			Where("user_privacy.id IS NULL OR user_privacy.search_by_email = ?", true).
+			Scan(&contacts).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询通讯录联系人失败")
+			return exception.ErrNotFound
+		}
+		profiles := make([]*types.PublicProfile, 0, len(contacts))
+		for i := range contacts {
+			profiles = append(profiles, &contacts[i].PublicProfile)
+		}
+		s.hideLastSeen(ctx, claims.UserId, profiles...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// BackfillEmailHash 为历史用户补充邮箱哈希，并重新计算与当前盐值不一致的哈希，启动时执行一次
+// 修改 CONTACT_DISCOVERY_SALT 后重启即可更新所有用户的哈希，客户端需要重新获取盐值
+func (s *service) BackfillEmailHash(ctx context.Context) error {
+	var lastId uint
+	for {
+		var users []model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).
+			Select("id, email, email_hash").
+			Where("id > ?", lastId).
+			Order("id").
+			Limit(defines.EMAIL_HASH_BATCH).
+			Find(&users).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询待补充邮箱哈希的用户失败")
+			return err
+		}
+		for _, user := range users {
+			hash := utils.HashEmail(user.Email)
+			if user.EmailHash == hash {
+				continue
+			}
+			// 只更新哈希列，不触发乐观锁版本与更新时间的变化
+			if err := s.GetDB(ctx).Model(&model.User{}).
+				Where("id = ?", user.ID).
+				UpdateColumn("email_hash", hash).Error; err != nil {
+				log.Logger.Error().Err(err).Msg("补充邮箱哈希失败")
+				return err
+			}
+		}
+		if len(users) < defines.EMAIL_HASH_BATCH {
+			return nil
+		}
+		lastId = users[len(users)-1].ID
+	}
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm/clause"
+)
+
+type ContactSyncService interface {
+	SyncFriendList(ctx context.Context, claims *types.GIClaims, sync request.FriendSync) (*types.FriendSync, error)
+}
+
+// SyncFriendList 增量同步好友列表
+// 客户端携带本地保存的通讯录版本号，服务端只返回该版本之后的变更（包括删除）。
+// 版本号为0或大于服务端当前版本时，返回完整的好友列表。
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	sync request.FriendSync: 客户端本地的通讯录版本
+//
+// 返回值:
+//
+//	*types.FriendSync: 当前版本号以及完整列表或变更列表
+//	error: 查询失败时返回的错误
+func (s *service) SyncFriendList(ctx context.Context, claims *types.GIClaims, sync request.FriendSync) (*types.FriendSync, error) {
+	var result types.FriendSync
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		version, err := s.currentContactVersion(ctx, claims.UserId)
+		if err != nil {
+			return err
+		}
+		result.Version = version
+		// 客户端没有本地数据或版本异常时，下发全量列表
+		if sync.Version == 0 || sync.Version > version {
+			result.Full = true
+			if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+				Select("user.uuid, user.username, user.nickname, user.avatar, user.last_seen,user_friend.status").
+				Joins("JOIN user ON user_friend.friendid = user.uuid").
+				Where("user_friend.userid = ? AND user_friend.status != ?", claims.UserId, enums.NOT_FRIEND).
+				Scan(&result.Friends).Error; err != nil {
+				log.Logger.Error().Err(err).Msg("查询失败")
+				return exception.ErrNotFound
+			}
+			s.hideLastSeen(ctx, claims.UserId, friendProfiles(result.Friends)...)
+			return nil
+		}
+		if sync.Version == version {
+			return nil
+		}
+		// 按版本顺序读取变更，同一好友只保留最后一次变更
+		var logs []model.ContactLog
+		if err := s.GetDB(ctx).Model(&model.ContactLog{}).
+			Where("userid = ? AND version > ?", claims.UserId, sync.Version).
+			Order("version").
+			Find(&logs).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询通讯录变更失败")
+			return exception.ErrNotFound
+		}
+		latest := make(map[string]int8)
+		var friendIds []string
+		for _, contactLog := range logs {
+			if _, ok := latest[contactLog.FriendId]; !ok {
+				friendIds = append(friendIds, contactLog.FriendId)
+			}
+			latest[contactLog.FriendId] = contactLog.Op
+		}
+		var friends []types.Friend
+		if err := s.GetDB(ctx).Model(&model.User{}).
+			Select("uuid, username, nickname, avatar, last_seen").
+			Where("uuid IN ?", friendIds).
+			Scan(&friends).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询失败")
+			return exception.ErrNotFound
+		}
+		friendMap := make(map[string]types.Friend, len(friends))
+		for _, friend := range friends {
+			friendMap[friend.Uuid] = friend
+		}
+		for _, friendId := range friendIds {
+			friend, ok := friendMap[friendId]
+			if !ok {
+				// 好友账号已不存在，只能返回标识用于客户端删除
+				friend = types.Friend{PublicProfile: types.PublicProfile{Uuid: friendId}}
+			}
+			if latest[friendId] == int8(enums.CONTACT_REMOVE) {
+				friend.Status = int8(enums.NOT_FRIEND)
+			} else {
+				friend.Status = int8(enums.IS_FRIEND)
+			}
+			result.Changes = append(result.Changes, types.ContactChange{Op: latest[friendId], Friend: friend})
+		}
+		profiles := make([]*types.PublicProfile, 0, len(result.Changes))
+		for i := range result.Changes {
+			profiles = append(profiles, &result.Changes[i].Friend.PublicProfile)
+		}
+		s.hideLastSeen(ctx, claims.UserId, profiles...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// currentContactVersion 获取用户当前的通讯录版本号，即最后一条变更记录的版本号
+func (s *service) currentContactVersion(ctx context.Context, userId string) (int64, error) {
+	var version int64
+	if err := s.GetDB(ctx).Model(&model.ContactLog{}).Select("COALESCE(MAX(version), 0)").Where("userid = ?", userId).Scan(&version).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询通讯录版本失败")
+		return 0, err
+	}
+	return version, nil
+}
+
+// lockContactVersion 锁定用户的通讯录版本计数行并返回，同一用户的变更在该行上串行执行
+// 计数行不存在时先创建；已有变更记录的用户首次创建计数行时，从最后一条变更的版本号开始计数
+func (s *service) lockContactVersion(ctx context.Context, userId string) (*model.ContactVersion, error) {
+	if err := s.GetDB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.ContactVersion{UserId: userId}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("创建通讯录版本失败")
+		return nil, err
+	}
+	var counter model.ContactVersion
+	if err := s.GetDB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("userid = ?", userId).First(&counter).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询通讯录版本失败")
+		return nil, err
+	}
+	if counter.Version == 0 {
+		// 持有计数行的锁之后不会再有并发的变更，加锁读取可以得到已提交的最大版本号
+		if err := s.GetDB(ctx).Model(&model.ContactLog{}).Clauses(clause.Locking{Strength: "SHARE"}).
+			Select("COALESCE(MAX(version), 0)").Where("userid = ?", userId).Scan(&counter.Version).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询通讯录版本失败")
+			return nil, err
+		}
+	}
+	return &counter, nil
+}
+
+// recordContactChange 记录一条通讯录变更，并将该用户的通讯录版本号加一
+func (s *service) recordContactChange(ctx context.Context, op enums.ContactOpEnum, userId, friendId string) error {
+	counter, err := s.lockContactVersion(ctx, userId)
+	if err != nil {
+		return err
+	}
+	counter.Version++
+	if err := s.GetDB(ctx).Model(counter).UpdateColumn("version", counter.Version).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("更新通讯录版本失败")
+		return err
+	}
+	if err := s.GetDB(ctx).Create(&model.ContactLog{
+		UserId:   userId,
+		FriendId: friendId,
+		Version:  counter.Version,
+		Op:       int8(op),
+	}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("记录通讯录变更失败")
+		return err
+	}
+	return nil
+}
+
+// recordFriendshipChange 好友关系变化会同时影响双方的通讯录，因此为双方各记录一条变更
+// 按用户ID的顺序锁定双方的计数行，避免两个方向的并发变更互相等待
+func (s *service) recordFriendshipChange(ctx context.Context, op enums.ContactOpEnum, userId, friendId string) error {
+	first, second := userId, friendId
+	if second < first {
+		first, second = second, first
+	}
+	for _, id := range []string{first, second} {
+		if _, err := s.lockContactVersion(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := s.recordContactChange(ctx, op, userId, friendId); err != nil {
+		return err
+	}
+	return s.recordContactChange(ctx, op, friendId, userId)
+}
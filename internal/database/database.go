@@ -1,7 +1,11 @@
 package database
 
 import (
+	"Gin-IM/internal/ldap"
+	"Gin-IM/internal/mailer"
 	"Gin-IM/internal/minio"
+	"Gin-IM/internal/notify"
+	"Gin-IM/internal/oidc"
 	"context"
 	"fmt"
 	_ "github.com/joho/godotenv/autoload"
@@ -40,12 +44,34 @@ type Service interface {
 	UserService
 	UserFriendService
 	FileService
+	FileLibraryService
+	FriendSuggestService
+	ContactSyncService
+	PrivacyService
+	InviteService
+	ContactDiscoverService
+	SessionService
+	EmailVerifyService
+	PasswordResetService
+	ProfileService
+	TwoFactorService
+	LoginGuardService
+	BanService
+	AccountDeleteService
+	OAuthService
+	RoleService
+	BotService
+	LoginHistoryService
 }
 
 type service struct {
 	db        *gorm.DB
 	valClient valkey.Client
 	minClient *minio.MinIOStore
+	mailer    mailer.Mailer
+	oidc      map[string]*oidc.Provider
+	ldap      *ldap.Config
+	notifier  notify.Notifier
 }
 
 var (
@@ -102,7 +128,11 @@ func New() Service {
 		db:        db,
 		valClient: valClient,
 		minClient: minio.NewClient(false),
+		mailer:    mailer.NewMailer(),
+		oidc:      oidc.LoadProviders(),
+		ldap:      ldap.LoadConfig(),
 	}
+	dbInstance.notifier = notify.NewNotifier(dbInstance.mailer, dbInstance.Publish)
 	return dbInstance
 }
 
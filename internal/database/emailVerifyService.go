@@ -0,0 +1,118 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"math/big"
+	"time"
+)
+
+type EmailVerifyService interface {
+	VerifyEmail(ctx context.Context, verify request.EmailVerify) error
+	ResendVerifyEmail(ctx context.Context, resend request.EmailResend) error
+}
+
+// VerifyEmail 校验邮件中的验证码并激活账号
+// 同一账号连续输错超过 EMAIL_VERIFY_ATTEMPTS 次后验证码作废，需要重新发送
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	verify request.EmailVerify: 邮箱与验证码
+//
+// 返回值:
+//
+//	error: 验证码错误或已失效时返回 ErrCheckCode，不区分邮箱是否存在
+func (s *service) VerifyEmail(ctx context.Context, verify request.EmailVerify) error {
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("email = ?", verify.Email).First(&user).Error; err != nil {
+		return exception.ErrCheckCode
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	code := s.GetValue(ctx, defines.EMAIL_VERIFY+user.Uuid)
+	if code == "" {
+		return exception.ErrCheckCode
+	}
+	if subtle.ConstantTimeCompare([]byte(code), []byte(verify.Code)) != 1 {
+		if count, err := s.IncrAndTime(ctx, defines.EMAIL_VERIFY_FAIL+user.Uuid, 1, defines.EMAIL_VERIFY_TIMEOUT); err == nil && count >= defines.EMAIL_VERIFY_ATTEMPTS {
+			_ = s.DelValue(ctx, defines.EMAIL_VERIFY+user.Uuid, defines.EMAIL_VERIFY_FAIL+user.Uuid)
+		}
+		return exception.ErrCheckCode
+	}
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("id = ?", user.ID).UpdateColumn("email_verified", true).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("更新邮箱验证状态失败")
+		return err
+	}
+	_ = s.DelValue(ctx, defines.EMAIL_VERIFY+user.Uuid, defines.EMAIL_VERIFY_FAIL+user.Uuid)
+	return nil
+}
+
+// ResendVerifyEmail 重新发送验证邮件
+// 无论邮箱是否存在、是否已经验证都返回成功，避免被用来探测邮箱是否注册
+func (s *service) ResendVerifyEmail(ctx context.Context, resend request.EmailResend) error {
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("email = ?", resend.Email).First(&user).Error; err != nil {
+		return nil
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	// 限制发送频率
+	if s.GetValue(ctx, defines.EMAIL_VERIFY_COOLDOWN+user.Uuid) != "" {
+		return nil
+	}
+	if err := s.sendVerifyCode(ctx, &user); err != nil {
+		log.Logger.Error().Err(err).Msg("发送验证邮件失败")
+	}
+	return nil
+}
+
+// sendVerifyCode 生成新的6位验证码并在后台发送到用户邮箱，旧的验证码随之失效
+func (s *service) sendVerifyCode(ctx context.Context, user *model.User) error {
+	code, err := randomDigits(6)
+	if err != nil {
+		return err
+	}
+	if err := s.SetAndTime(ctx, defines.EMAIL_VERIFY+user.Uuid, code, defines.EMAIL_VERIFY_TIMEOUT); err != nil {
+		return err
+	}
+	_ = s.DelValue(ctx, defines.EMAIL_VERIFY_FAIL+user.Uuid)
+	if err := s.SetAndTime(ctx, defines.EMAIL_VERIFY_COOLDOWN+user.Uuid, "1", defines.EMAIL_RESEND_INTERVAL); err != nil {
+		log.Logger.Error().Err(err).Msg("设置发送间隔失败")
+	}
+	body := fmt.Sprintf("%s，你好：\n\n你的邮箱验证码是 %s，%d 分钟内有效。\n如果这不是你本人的操作，请忽略本邮件。",
+		user.Username, code, defines.EMAIL_VERIFY_TIMEOUT/60)
+	s.sendMail(user.Email, "邮箱验证", body)
+	return nil
+}
+
+// sendMail 在后台发送邮件，发送失败只记录日志
+// 请求受 TimeoutMiddleware 限制，同步等待邮件服务器会让已注册的邮箱明显变慢甚至超时，从而暴露邮箱是否注册；
+// 请求结束后不能再使用请求的上下文
+func (s *service) sendMail(to, subject, body string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defines.MAIL_SEND_TIMEOUT*time.Second)
+		defer cancel()
+		if err := s.mailer.Send(ctx, to, subject, body); err != nil {
+			log.Logger.Error().Err(err).Str("subject", subject).Msg("发送邮件失败")
+		}
+	}()
+}
+
+// randomDigits 使用密码学安全的随机数生成指定长度的数字验证码
+func randomDigits(length int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", length, n), nil
+}
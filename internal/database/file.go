@@ -61,18 +61,20 @@ func (s *service) UploadFile(ctx context.Context, claims *types.GIClaims, upload
 		if file, isExist := s.CheckIsExist(ctx, upload.Md5, upload.Sha1); isExist {
 			if err := s.UploadFileToDB(ctx, claims, file.UploadId, objectName, file.FileName, file.Md5, file.Sha1, enums.FILEUPLOADED); err != nil {
 				return err
-			} else {
-				return nil
 			}
+			// 已有文件的元数据直接复制给新的记录。
+			if file.ContentType != "" {
+				return s.setFileMetadata(ctx, objectName, file.Size, file.ContentType)
+			}
+			return nil
 		}
 
 		// 检查MinIO中是否已存在该对象，如果存在，则更新数据库并结束上传初始化过程。
-		if _, err := s.minClient.StatusObject(ctx, objectName); err == nil {
+		if objectInfo, err := s.minClient.StatusObject(ctx, objectName); err == nil {
 			if err := s.UploadFileToDB(ctx, claims, uploadInfo.UploadId, objectName, upload.FileName, upload.Md5, upload.Sha1, enums.FILEUPLOADED); err != nil {
 				return err
-			} else {
-				return nil
 			}
+			return s.setFileMetadata(ctx, objectName, objectInfo.Size, objectInfo.ContentType)
 		}
 
 		// 初始化分片上传，获取上传URL。
@@ -117,6 +119,8 @@ func (s *service) UploadFileToDB(ctx context.Context, claims *types.GIClaims, up
 	file.UploadId = uploadId
 	file.ObjectName = objectName
 	file.FileName = fileName
+	// 文件类型用于在文件库中筛选。
+	file.FileType = utils.FileTypeOf(fileName)
 	// 从claims中获取用户ID，作为文件的拥有者
 	file.Owner = claims.UserId
 	file.Md5 = md5
@@ -311,6 +315,10 @@ func (s *service) MergeFile(ctx context.Context, claims *types.GIClaims, merge r
 				_ = s.minClient.DeleteFiles(ctx, true, objectName)
 				return exception.ErrFileUploading
 			}
+			// 保存对象的大小与内容类型。
+			if err := s.setFileMetadata(ctx, objectName, objectInfo.Size, objectInfo.ContentType); err != nil {
+				return err
+			}
 		}
 
 		// 删除缓存中的上传ID。
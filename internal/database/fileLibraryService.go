@@ -0,0 +1,210 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"time"
+)
+
+type FileLibraryService interface {
+	ListFiles(ctx context.Context, claims *types.GIClaims, list request.FileList) (*types.FileList, error)
+	BackfillFileMetadata(ctx context.Context) error
+}
+
+// fileSortColumns 排序字段对应的列
+var fileSortColumns = map[string]string{
+	"date": "created_at",
+	"name": "filename",
+	"size": "size",
+}
+
+// fileCursor 游标中保存上一页最后一个文件的排序列的值与ID，排序方式改变后游标失效
+type fileCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	Id    uint   `json:"i"`
+}
+
+// ListFiles 列出当前用户的文件，不包括回收站中的文件
+// 可以按文件类型、状态与文件名筛选，按上传时间、文件名或大小排序，使用游标分页，排序值相同时按ID排序保证顺序稳定。
+// 未指定排序方向时，按时间和大小降序，按文件名升序。
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	list request.FileList: 筛选条件、排序方式与上一页返回的游标
+//
+// 返回值:
+//
+//	*types.FileList: 文件列表与下一页的游标
+//	error: 游标无效或查询失败时返回的错误
+func (s *service) ListFiles(ctx context.Context, claims *types.GIClaims, list request.FileList) (*types.FileList, error) {
+	if list.Sort == "" {
+		list.Sort = "date"
+	}
+	if list.Order == "" {
+		list.Order = "desc"
+		if list.Sort == "name" {
+			list.Order = "asc"
+		}
+	}
+	if list.Limit == 0 {
+		list.Limit = defines.FILE_LIST_SIZE
+	}
+	column := fileSortColumns[list.Sort]
+	db := s.GetDB(ctx).Model(&model.File{}).Where("owner = ?", claims.UserId)
+	if list.Type != "" {
+		db = db.Where("filetype = ?", list.Type)
+	}
+	if list.Status != nil {
+		db = db.Where("status = ?", *list.Status)
+	}
+	if list.Name != "" {
+		db = db.Where("filename LIKE ?", "%"+escapeLike(list.Name)+"%")
+	}
+	if list.Cursor != "" {
+		cursor, value, err := decodeFileCursor(list.Cursor)
+		if err != nil || cursor.Sort != list.Sort || cursor.Order != list.Order {
+			return nil, exception.ErrBadRequest
+		}
+		op := "<"
+		if list.Order == "asc" {
+			op = ">"
+		}
+		db = db.Where("("+column+" "+op+" ? OR ("+column+" = ? AND id "+op+" ?))", value, value, cursor.Id)
+	}
+	var files []model.File
+	// 多查一条用于判断是否还有下一页
+	if err := db.Select("id, md5, sha1, filename, filetype, status, size, contenttype, created_at, updated_at").
+		Order(column + " " + list.Order).
+		Order("id " + list.Order).
+		Limit(list.Limit + 1).
+		Find(&files).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询文件列表失败")
+		return nil, err
+	}
+	result := &types.FileList{Files: make([]types.FileInfo, 0, len(files))}
+	if len(files) > list.Limit {
+		files = files[:list.Limit]
+		next, err := encodeFileCursor(list.Sort, list.Order, &files[len(files)-1])
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = next
+	}
+	for _, file := range files {
+		result.Files = append(result.Files, types.FileInfo{
+			Md5:         file.Md5,
+			Sha1:        file.Sha1,
+			FileName:    file.FileName,
+			FileType:    file.FileType,
+			Status:      file.Status,
+			Size:        file.Size,
+			ContentType: file.ContentType,
+			CreatedAt:   file.CreatedAt,
+			UpdatedAt:   file.UpdatedAt,
+		})
+	}
+	return result, nil
+}
+
+// BackfillFileMetadata 为历史文件补充文件类型，并从对象存储读取已上传文件的大小与内容类型，启动时执行一次
+func (s *service) BackfillFileMetadata(ctx context.Context) error {
+	var lastId uint
+	for {
+		var files []model.File
+		if err := s.GetDB(ctx).Unscoped().Model(&model.File{}).
+			Select("id, objectname, filename, status").
+			Where("id > ? AND (filetype IS NULL OR filetype = '' OR (status = ? AND (contenttype IS NULL OR contenttype = '')))", lastId, enums.FILEUPLOADED).
+			Order("id").
+			Limit(defines.FILE_METADATA_BATCH).
+			Find(&files).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询待补充元数据的文件失败")
+			return err
+		}
+		for _, file := range files {
+			columns := map[string]interface{}{"filetype": utils.FileTypeOf(file.FileName)}
+			if file.Status == int8(enums.FILEUPLOADED) {
+				if info, err := s.minClient.StatusObject(ctx, file.ObjectName); err != nil {
+					log.Logger.Warn().Err(err).Str("objectName", file.ObjectName).Msg("读取文件元数据失败")
+				} else {
+					columns["size"] = info.Size
+					columns["contenttype"] = info.ContentType
+				}
+			}
+			// 只更新元数据列，不触发乐观锁版本与更新时间的变化
+			if err := s.GetDB(ctx).Unscoped().Model(&model.File{}).
+				Where("id = ?", file.ID).
+				UpdateColumns(columns).Error; err != nil {
+				log.Logger.Error().Err(err).Msg("补充文件元数据失败")
+				return err
+			}
+		}
+		if len(files) < defines.FILE_METADATA_BATCH {
+			return nil
+		}
+		lastId = files[len(files)-1].ID
+	}
+}
+
+// setFileMetadata 保存对象的大小与内容类型，内容相同的文件共用一个对象，引用该对象的所有记录一起更新
+func (s *service) setFileMetadata(ctx context.Context, objectName string, size int64, contentType string) error {
+	if err := s.GetDB(ctx).Unscoped().Model(&model.File{}).
+		Where("objectname = ?", objectName).
+		UpdateColumns(map[string]interface{}{"size": size, "contenttype": contentType}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("保存文件元数据失败")
+		return err
+	}
+	return nil
+}
+
+// encodeFileCursor 根据本页最后一个文件生成下一页的游标
+func encodeFileCursor(sort, order string, file *model.File) (string, error) {
+	cursor := fileCursor{Sort: sort, Order: order, Id: file.ID}
+	switch sort {
+	case "name":
+		cursor.Value = file.FileName
+	case "size":
+		cursor.Value = strconv.FormatInt(file.Size, 10)
+	default:
+		cursor.Value = file.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeFileCursor 解析游标，返回游标与转换为排序列类型的值
+func decodeFileCursor(value string) (*fileCursor, interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cursor fileCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, nil, err
+	}
+	switch cursor.Sort {
+	case "name":
+		return &cursor, cursor.Value, nil
+	case "size":
+		size, err := strconv.ParseInt(cursor.Value, 10, 64)
+		return &cursor, size, err
+	default:
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		return &cursor, createdAt, err
+	}
+}
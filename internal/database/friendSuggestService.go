@@ -0,0 +1,122 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/types"
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+)
+
+type FriendSuggestService interface {
+	GetFriendSuggestions(ctx context.Context, claims *types.GIClaims) ([]types.FriendSuggestion, error)
+	RefreshFriendSuggestions(ctx context.Context) error
+}
+
+// GetFriendSuggestions 获取“可能认识的人”列表
+// 优先读取缓存中的推荐结果，缓存不存在时才实时计算并写入缓存
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//
+// 返回值:
+//
+//	[]types.FriendSuggestion: 按共同好友数量降序排列的推荐列表
+//	error: 查询失败时返回的错误
+func (s *service) GetFriendSuggestions(ctx context.Context, claims *types.GIClaims) ([]types.FriendSuggestion, error) {
+	// 命中缓存则直接返回
+	if cached := s.GetValue(ctx, defines.FRIEND_SUGGEST+claims.UserId); cached != "" {
+		var suggestions []types.FriendSuggestion
+		if err := json.Unmarshal([]byte(cached), &suggestions); err == nil {
+			s.hideSuggestionLastSeen(ctx, claims.UserId, suggestions)
+			return suggestions, nil
+		}
+		log.Logger.Error().Str("userId", claims.UserId).Msg("好友推荐缓存解析失败")
+	}
+	suggestions, err := s.refreshFriendSuggestion(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	s.hideSuggestionLastSeen(ctx, claims.UserId, suggestions)
+	return suggestions, nil
+}
+
+// hideSuggestionLastSeen 缓存中保存的是原始资料，返回前按隐私设置隐藏最后在线时间
+func (s *service) hideSuggestionLastSeen(ctx context.Context, userId string, suggestions []types.FriendSuggestion) {
+	profiles := make([]*types.PublicProfile, 0, len(suggestions))
+	for i := range suggestions {
+		profiles = append(profiles, &suggestions[i].PublicProfile)
+	}
+	s.hideLastSeen(ctx, userId, profiles...)
+}
+
+// RefreshFriendSuggestions 分批为所有拥有好友的用户重新计算推荐结果并写入缓存
+// 由定时任务调用，避免每次请求都执行好友关系图查询
+func (s *service) RefreshFriendSuggestions(ctx context.Context) error {
+	lastId := ""
+	for {
+		var userIds []string
+		// 按 userid 游标分批读取，避免一次性加载全部用户
+		if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+			Distinct().
+			Where("status = ? AND userid > ?", enums.IS_FRIEND, lastId).
+			Order("userid").
+			Limit(defines.FRIEND_SUGGEST_BATCH).
+			Pluck("userid", &userIds).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询待刷新推荐的用户失败")
+			return err
+		}
+		for _, userId := range userIds {
+			if _, err := s.refreshFriendSuggestion(ctx, userId); err != nil {
+				return err
+			}
+		}
+		if len(userIds) < defines.FRIEND_SUGGEST_BATCH {
+			return nil
+		}
+		lastId = userIds[len(userIds)-1]
+	}
+}
+
+// refreshFriendSuggestion 计算单个用户的推荐结果并写入缓存
+func (s *service) refreshFriendSuggestion(ctx context.Context, userId string) ([]types.FriendSuggestion, error) {
+	suggestions, err := s.queryFriendSuggestions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(suggestions); err == nil {
+		if err := s.SetAndTime(ctx, defines.FRIEND_SUGGEST+userId, string(data), defines.FRIEND_SUGGEST_TIMEOUT); err != nil {
+			log.Logger.Error().Err(err).Msg("写入好友推荐缓存失败")
+		}
+	}
+	return suggestions, nil
+}
+
+// queryFriendSuggestions 根据 user_friend 关系图统计二度好友的共同好友数量
+// 已存在任何关系（好友、待验证、拉黑与被拉黑）的用户都会被排除
+func (s *service) queryFriendSuggestions(ctx context.Context, userId string) ([]types.FriendSuggestion, error) {
+	suggestions := make([]types.FriendSuggestion, 0)
+	// 与当前用户存在任意关系的用户，包含被软删除的拉黑记录
+	related := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).Select("friendid").Where("userid = ?", userId)
+	relatedBy := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).Select("userid").Where("friendid = ?", userId)
+	if err := s.GetDB(ctx).Table("user_friend AS f1").
+		Select("user.uuid, user.username, user.nickname, user.avatar, user.last_seen, COUNT(*) AS mutual").
+		Joins("JOIN user_friend AS f2 ON f2.userid = f1.friendid AND f2.status = ? AND f2.deleted_at IS NULL", enums.IS_FRIEND).
+		Joins("JOIN user ON user.uuid = f2.friendid AND user.deleted_at IS NULL").
+		Where("f1.userid = ? AND f1.status = ? AND f1.deleted_at IS NULL", userId, enums.IS_FRIEND).
+		Where("f2.friendid <> ?", userId).
+		Where("f2.friendid NOT IN (?)", related).
+		Where("f2.friendid NOT IN (?)", relatedBy).
+		Group("user.uuid, user.username, user.nickname, user.avatar, user.last_seen").
+		Order("mutual DESC").
+		Limit(defines.FRIEND_SUGGEST_LIMIT).
+		Scan(&suggestions).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询好友推荐失败")
+		return nil, exception.ErrNotFound
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+type InviteService interface {
+	CreateInvite(ctx context.Context, claims *types.GIClaims, invite request.InviteCreate) (*types.InviteQRCode, error)
+	RedeemInvite(ctx context.Context, claims *types.GIClaims, redeem request.InviteRedeem) error
+}
+
+// CreateInvite 生成带签名与有效期的邀请令牌，并渲染为二维码
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	invite request.InviteCreate: 邀请类型，目前只支持添加好友
+//
+// 返回值:
+//
+//	*types.InviteQRCode: 邀请令牌、base64 编码的二维码图片以及过期时间
+//	error: 生成失败时返回的错误
+func (s *service) CreateInvite(ctx context.Context, claims *types.GIClaims, invite request.InviteCreate) (*types.InviteQRCode, error) {
+	var target string
+	switch enums.InviteKindEnum(invite.Kind) {
+	case enums.INVITE_FRIEND:
+		// 添加好友的二维码只能邀请别人添加自己
+		target = claims.UserId
+	default:
+		// 群组尚未实现，其余邀请类型都是无效参数
+		return nil, exception.ErrBadRequest
+	}
+	expiresAt := time.Now().Add(time.Hour * defines.INVITE_TOKEN_EXPIRE)
+	tokenString := token.GernerateToken(types.InviteClaims{
+		Kind:   invite.Kind,
+		Target: target,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    claims.UserId,
+			Audience:  jwt.ClaimStrings{defines.INVITE_AUDIENCE},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	})
+	if tokenString == "" {
+		return nil, exception.ErrInvalidInvite
+	}
+	b64s, err := utils.GenerateQRCodeBase64(tokenString)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("生成二维码失败")
+		return nil, exception.ErrInvalidInvite
+	}
+	return &types.InviteQRCode{
+		Token:     tokenString,
+		B64s:      b64s,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// RedeemInvite 校验扫码得到的邀请令牌并发起对应的流程
+// 添加好友时不受对方“允许通过邮箱/用户名查找”设置的限制，但仍遵守对方的好友申请策略
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	redeem request.InviteRedeem: 邀请令牌以及对方要求的验证答案
+//
+// 返回值:
+//
+//	error: 令牌无效、过期或申请失败时返回的错误
+func (s *service) RedeemInvite(ctx context.Context, claims *types.GIClaims, redeem request.InviteRedeem) error {
+	invite, err := token.ParseInviteToken(redeem.Token)
+	if err != nil {
+		return err
+	}
+	switch enums.InviteKindEnum(invite.Kind) {
+	case enums.INVITE_FRIEND:
+		if invite.Target == claims.UserId {
+			return exception.ErrBadRequest
+		}
+		return s.Transaction(ctx, func(ctx context.Context) error {
+			var friend model.User
+			if err := s.GetDB(ctx).Model(&model.User{}).
+				Where("uuid = ? AND status <> ?", invite.Target, enums.Forbid).
+				First(&friend).Error; err != nil {
+				return exception.ErrInvalidInvite
+			}
+			return s.requestFriend(ctx, claims.UserId, &friend, redeem.Answer)
+		})
+	default:
+		return exception.ErrInvalidInvite
+	}
+}
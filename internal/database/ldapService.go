@@ -0,0 +1,96 @@
+package database
+
+import (
+	"Gin-IM/internal/ldap"
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/utils"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"strings"
+)
+
+// isDirectoryUser 用户是否为通过 LDAP 登录创建或关联的目录账号
+func (s *service) isDirectoryUser(ctx context.Context, userId string) bool {
+	var count int64
+	s.GetDB(ctx).Model(&model.UserIdentity{}).Where("userid = ? AND provider = ?", userId, defines.LDAP_PROVIDER).Count(&count)
+	return count != 0
+}
+
+// ldapLogin 通过 LDAP 验证邮箱与密码，需在事务中调用
+// 用户首次登录时创建本地账号并关联目录条目，之后每次登录都按所在的组同步管理员角色。
+// user 为空记录时表示本地尚无该邮箱的账号，认证成功后写入新创建的账号。
+func (s *service) ldapLogin(ctx context.Context, email, password string, user *model.User) error {
+	entry, err := s.ldap.Authenticate(ctx, email, password)
+	if err != nil {
+		if !errors.Is(err, ldap.ErrInvalidCredentials) {
+			log.Logger.Error().Err(err).Msg("LDAP 认证失败")
+		}
+		return exception.ErrLoginFailed
+	}
+	// DN 模板可能只使用邮箱 @ 之前的部分，目录条目的邮箱必须与登录邮箱一致，否则任意域名的邮箱都能冒用同名的目录账号
+	if entry.Email == "" || !strings.EqualFold(entry.Email, email) {
+		log.Logger.Warn().Str("dn", entry.DN).Msg("LDAP 条目的邮箱与登录邮箱不一致")
+		return exception.ErrLoginFailed
+	}
+	if user.Uuid == "" {
+		created, err := s.createDirectoryUser(ctx, entry)
+		if err != nil {
+			return err
+		}
+		*user = *created
+	}
+	if err := s.setRole(ctx, user.Uuid, enums.ROLE_ADMIN, entry.Admin); err != nil {
+		log.Logger.Error().Err(err).Msg("同步管理员角色失败")
+		return err
+	}
+	return nil
+}
+
+// createDirectoryUser 为首次通过 LDAP 登录的用户创建账号并关联目录条目
+// 账号的密码由目录管理，本地保存的是随机密码，不能用于登录；账号使用目录条目中的邮箱
+func (s *service) createDirectoryUser(ctx context.Context, entry *ldap.User) (*model.User, error) {
+	email := entry.Email
+	// 邮箱被宽限期内的已注销账号占用
+	var count int64
+	if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count != 0 {
+		return nil, exception.ErrLoginFailed
+	}
+	username, err := s.uniqueUsername(ctx, "", email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+	user := model.User{
+		Uuid:      uuid.New().String(),
+		Username:  username,
+		Nickname:  truncateRunes(entry.Name, 32),
+		Password:  password,
+		Email:     email,
+		EmailHash: utils.HashEmail(email),
+	}
+	if err := s.GetDB(ctx).Create(&user).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("创建用户失败")
+		return nil, err
+	}
+	if err := s.GetDB(ctx).Create(&model.UserIdentity{
+		UserId:   user.Uuid,
+		Provider: defines.LDAP_PROVIDER,
+		Subject:  entry.DN,
+		Email:    entry.Email,
+	}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("关联目录账号失败")
+		return nil, err
+	}
+	return &user, nil
+}
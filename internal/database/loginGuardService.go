@@ -0,0 +1,146 @@
+package database
+
+import (
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"github.com/rs/zerolog/log"
+	"os"
+)
+
+type LoginGuardService interface {
+	CheckLoginLock(ctx context.Context, ip, email string) error
+	RecordLoginFailure(ctx context.Context, ip, email string)
+	UnlockLogin(ctx context.Context, claims *types.GIClaims, unlock request.LoginUnlock) error
+	CaptchaRequired(ctx context.Context, ip, email, deviceId string) bool
+}
+
+// captchaAlways 为 true 时每次登录都需要验证码，对应 CAPTCHA_POLICY=always
+var captchaAlways = os.Getenv("CAPTCHA_POLICY") == "always"
+
+// ipScope 与 accountScope 为失败计数区分来源，账号使用邮箱哈希，不要求邮箱真实存在
+func ipScope(ip string) string {
+	return "ip:" + ip
+}
+
+func accountScope(email string) string {
+	return "account:" + utils.HashEmail(email)
+}
+
+// CheckLoginLock 检查 IP 或账号是否处于锁定状态
+// 不存在的邮箱同样会被计数与锁定，锁定结果不会暴露邮箱是否注册
+func (s *service) CheckLoginLock(ctx context.Context, ip, email string) error {
+	if s.lockedScope(ctx, ip, email) != "" {
+		return exception.ErrLoginLocked
+	}
+	return nil
+}
+
+// lockedScope 返回处于锁定状态的 IP 或账号，都没有被锁定时返回空字符串
+func (s *service) lockedScope(ctx context.Context, ip, email string) string {
+	if s.GetValue(ctx, defines.LOGIN_LOCK+ipScope(ip)) != "" {
+		return ipScope(ip)
+	}
+	if email != "" && s.GetValue(ctx, defines.LOGIN_LOCK+accountScope(email)) != "" {
+		return accountScope(email)
+	}
+	return ""
+}
+
+// firstLockedAttempt 每次锁定期间只有第一次被拒绝的尝试返回 true，避免锁定期间的每次请求都写入登录记录
+// 标记在重新锁定或管理员解锁时清除
+func (s *service) firstLockedAttempt(ctx context.Context, ip, email string) bool {
+	scope := s.lockedScope(ctx, ip, email)
+	if scope == "" {
+		return false
+	}
+	first, err := s.SetNxAndTime(ctx, defines.LOGIN_LOCK_SEEN+scope, "1", defines.LOGIN_LOCK_MAX)
+	return err == nil && first
+}
+
+// RecordLoginFailure 记录一次失败的登录或验证码校验，email 为空时只计入 IP
+// 窗口期内失败次数达到阈值后锁定，每次锁定的时长是上一次的两倍，最长 LOGIN_LOCK_MAX
+func (s *service) RecordLoginFailure(ctx context.Context, ip, email string) {
+	s.recordFailure(ctx, ipScope(ip), defines.LOGIN_IP_ATTEMPTS)
+	if email != "" {
+		s.recordFailure(ctx, accountScope(email), defines.LOGIN_ACCOUNT_ATTEMPTS)
+	}
+}
+
+// UnlockLogin 管理员解除账号或 IP 的登录锁定，同时清除失败计数与锁定等级
+func (s *service) UnlockLogin(ctx context.Context, claims *types.GIClaims, unlock request.LoginUnlock) error {
+	var scopes []string
+	if unlock.Email != "" {
+		scopes = append(scopes, accountScope(unlock.Email))
+	}
+	if unlock.Ip != "" {
+		scopes = append(scopes, ipScope(unlock.Ip))
+	}
+	for _, scope := range scopes {
+		if err := s.DelValue(ctx, defines.LOGIN_FAIL+scope, defines.LOGIN_LOCK+scope, defines.LOGIN_LOCK_LEVEL+scope, defines.LOGIN_LOCK_SEEN+scope); err != nil {
+			return err
+		}
+	}
+	log.Logger.Info().Str("admin", claims.UserId).Str("email", unlock.Email).Str("ip", unlock.Ip).Msg("解除登录锁定")
+	return nil
+}
+
+// CaptchaRequired 根据风险判断本次登录是否需要验证码
+// 设备标识与IP都在该账号最近的成功登录中出现过，并且IP与账号在窗口期内都没有失败记录时可以免除验证码，
+// 其余情况，包括任何一次失败之后，都需要验证码。
+func (s *service) CaptchaRequired(ctx context.Context, ip, email, deviceId string) bool {
+	if captchaAlways || deviceId == "" || email == "" {
+		return true
+	}
+	if s.GetValue(ctx, trustedDeviceKey(ip, email, deviceId)) == "" {
+		return true
+	}
+	return s.GetValue(ctx, defines.LOGIN_FAIL+ipScope(ip)) != "" ||
+		s.GetValue(ctx, defines.LOGIN_FAIL+accountScope(email)) != ""
+}
+
+// trustDevice 登录成功后记住设备与IP，之后在同一设备与IP上登录可以免除验证码
+func (s *service) trustDevice(ctx context.Context, ip, email, deviceId string) {
+	if deviceId == "" {
+		return
+	}
+	if err := s.SetAndTime(ctx, trustedDeviceKey(ip, email, deviceId), "1", defines.TRUSTED_DEVICE_TTL); err != nil {
+		log.Logger.Error().Err(err).Msg("记录可信设备失败")
+	}
+}
+
+// trustedDeviceKey 设备标识由客户端生成，只保存哈希值
+func trustedDeviceKey(ip, email, deviceId string) string {
+	return defines.TRUSTED_DEVICE + accountScope(email) + ":" + hashToken(deviceId) + ":" + ip
+}
+
+// clearLoginFailure 登录成功后清除账号的失败计数与锁定等级
+func (s *service) clearLoginFailure(ctx context.Context, email string) {
+	scope := accountScope(email)
+	_ = s.DelValue(ctx, defines.LOGIN_FAIL+scope, defines.LOGIN_LOCK_LEVEL+scope)
+}
+
+func (s *service) recordFailure(ctx context.Context, scope string, threshold int64) {
+	count, err := s.IncrAndTime(ctx, defines.LOGIN_FAIL+scope, 1, defines.LOGIN_FAIL_WINDOW)
+	if err != nil || count < threshold {
+		return
+	}
+	level, err := s.IncrAndTime(ctx, defines.LOGIN_LOCK_LEVEL+scope, 1, defines.LOGIN_LOCK_MAX)
+	if err != nil {
+		return
+	}
+	lock := int64(defines.LOGIN_LOCK_BASE)
+	for i := int64(1); i < level && lock < defines.LOGIN_LOCK_MAX; i++ {
+		lock *= 2
+	}
+	lock = min(lock, defines.LOGIN_LOCK_MAX)
+	if err := s.SetAndTime(ctx, defines.LOGIN_LOCK+scope, "1", lock); err != nil {
+		log.Logger.Error().Err(err).Msg("设置登录锁定失败")
+	}
+	// 锁定后重新开始计数，新的锁定期间第一次被拒绝的尝试需要重新记录
+	_ = s.DelValue(ctx, defines.LOGIN_FAIL+scope, defines.LOGIN_LOCK_SEEN+scope)
+	log.Logger.Warn().Str("scope", scope).Int64("seconds", lock).Msg("登录失败次数过多，已锁定")
+}
@@ -0,0 +1,262 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/internal/notify"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"net/netip"
+	"os"
+	"strconv"
+	"time"
+)
+
+type LoginHistoryService interface {
+	RecordLoginAttempt(ctx *gin.Context, email, device, reason string)
+	ListLoginHistory(ctx context.Context, claims *types.GIClaims, history request.LoginHistory) (*types.Page[types.LoginRecord], error)
+	AdminLoginHistory(ctx context.Context, history request.AdminLoginHistory) (*types.Page[types.LoginRecord], error)
+	PurgeLoginHistory(ctx context.Context) error
+}
+
+// loginAlertTimeout 发送登录提醒的超时时间，提醒在登录请求返回之后异步发送
+const loginAlertTimeout = 30 * time.Second
+
+// loginHistoryDays 登录记录的保留天数，由 LOGIN_HISTORY_RETENTION 配置，默认 LOGIN_HISTORY_DAYS
+var loginHistoryDays = loadLoginHistoryDays()
+
+func loadLoginHistoryDays() int {
+	if days, err := strconv.Atoi(os.Getenv("LOGIN_HISTORY_RETENTION")); err == nil && days > 0 {
+		return days
+	}
+	return defines.LOGIN_HISTORY_DAYS
+}
+
+// RecordLoginAttempt 记录在进入登录流程之前就被拒绝的尝试，例如账号被锁定或验证码错误
+// 锁定期间的尝试只记录第一次，之后的请求不再写入数据库
+// 参数:
+//
+//	ctx *gin.Context: Gin框架的上下文，用于读取IP与User-Agent
+//	email string: 登录邮箱，已注册时同时记录用户ID
+//	device string: 登录设备名称
+//	reason string: 失败原因，取值见 enums 中的 LOGIN_ 常量
+func (s *service) RecordLoginAttempt(ctx *gin.Context, email, device, reason string) {
+	if reason == enums.LOGIN_LOCKED && !s.firstLockedAttempt(ctx, ctx.ClientIP(), email) {
+		return
+	}
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("email = ?", email).First(&user).Error; err != nil {
+		user = model.User{Email: email}
+	}
+	s.recordLogin(ctx, &user, device, "", reason)
+}
+
+// ListLoginHistory 查询当前用户的登录记录，按时间倒序分页
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	history request.LoginHistory: 分页信息
+//
+// 返回值:
+//
+//	*types.Page[types.LoginRecord]: 分页后的登录记录
+//	error: 查询失败时返回的错误
+func (s *service) ListLoginHistory(ctx context.Context, claims *types.GIClaims, history request.LoginHistory) (*types.Page[types.LoginRecord], error) {
+	db := s.GetDB(ctx).Model(&model.LoginRecord{}).Where("userid = ?", claims.UserId)
+	return s.loginHistoryPage(db, history.Page, history.PageSize)
+}
+
+// AdminLoginHistory 管理员按用户、邮箱、IP 查询登录记录，可以只查看可疑的登录
+func (s *service) AdminLoginHistory(ctx context.Context, history request.AdminLoginHistory) (*types.Page[types.LoginRecord], error) {
+	db := s.GetDB(ctx).Model(&model.LoginRecord{})
+	if history.UserId != "" {
+		db = db.Where("userid = ?", history.UserId)
+	}
+	if history.Email != "" {
+		db = db.Where("email = ?", history.Email)
+	}
+	if history.Ip != "" {
+		db = db.Where("ip = ?", history.Ip)
+	}
+	if history.Suspicious {
+		db = db.Where("suspicious = ?", true)
+	}
+	return s.loginHistoryPage(db, history.Page, history.PageSize)
+}
+
+// PurgeLoginHistory 删除超过保留天数的登录记录，每次删除 LOGIN_HISTORY_BATCH 条，避免长时间锁表
+func (s *service) PurgeLoginHistory(ctx context.Context) error {
+	before := time.Now().AddDate(0, 0, -loginHistoryDays)
+	var total int64
+	for {
+		var ids []uint
+		if err := s.GetDB(ctx).Unscoped().Model(&model.LoginRecord{}).
+			Where("created_at < ?", before).
+			Order("id").
+			Limit(defines.LOGIN_HISTORY_BATCH).
+			Pluck("id", &ids).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询过期登录记录失败")
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := s.GetDB(ctx).Unscoped().Delete(&model.LoginRecord{}, ids).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("删除过期登录记录失败")
+			return err
+		}
+		total += int64(len(ids))
+		if len(ids) < defines.LOGIN_HISTORY_BATCH {
+			break
+		}
+	}
+	if total > 0 {
+		log.Logger.Info().Int64("count", total).Int("days", loginHistoryDays).Msg("已清理过期登录记录")
+	}
+	return nil
+}
+
+// loginHistoryPage 按时间倒序分页查询登录记录
+func (s *service) loginHistoryPage(db *gorm.DB, pageNum, pageSize int) (*types.Page[types.LoginRecord], error) {
+	page := &types.Page[types.LoginRecord]{
+		Page:     pageNum,
+		PageSize: pageSize,
+		List:     make([]types.LoginRecord, 0),
+	}
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.PageSize == 0 {
+		page.PageSize = defines.LOGIN_HISTORY_SIZE
+	}
+	db = db.Session(&gorm.Session{})
+	if err := db.Count(&page.Total).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询登录记录失败")
+		return nil, err
+	}
+	if err := db.Select("id, userid AS user_id, email, ip, user_agent, device, success, reason, suspicious, created_at").
+		Order("id DESC").
+		Offset((page.Page - 1) * page.PageSize).
+		Limit(page.PageSize).
+		Scan(&page.List).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询登录记录失败")
+		return nil, err
+	}
+	return page, nil
+}
+
+// recordLogin 保存一次登录尝试，reason 为空表示登录成功，保存失败只记录日志，不影响登录
+// 来自新设备或新网段的成功登录标记为可疑，并通过邮件与其他在线会话提醒用户
+func (s *service) recordLogin(ctx *gin.Context, user *model.User, device, sessionId, reason string) {
+	ip := ctx.ClientIP()
+	record := model.LoginRecord{
+		UserId:    user.Uuid,
+		Email:     user.Email,
+		Ip:        ip,
+		IpRange:   ipRange(ip),
+		UserAgent: truncateRunes(ctx.Request.UserAgent(), 255),
+		Device:    truncateRunes(device, 64),
+		Success:   reason == "",
+		Reason:    reason,
+	}
+	if record.Success && user.Uuid != "" {
+		record.Suspicious = s.unfamiliarLogin(ctx, &record)
+	}
+	if err := s.GetDB(ctx).Create(&record).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("保存登录记录失败")
+	}
+	if record.Suspicious {
+		alert := notify.LoginAlert{
+			UserId:    user.Uuid,
+			Username:  user.Username,
+			Email:     user.Email,
+			SessionId: sessionId,
+			Ip:        record.Ip,
+			Device:    record.Device,
+			UserAgent: record.UserAgent,
+			Time:      time.Now(),
+		}
+		// 邮件发送可能较慢，提醒在请求结束后发送，不能再使用请求的上下文
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), loginAlertTimeout)
+			defer cancel()
+			if err := s.notifier.NotifyLogin(ctx, alert); err != nil {
+				log.Logger.Error().Err(err).Str("userId", alert.UserId).Msg("发送登录提醒失败")
+			}
+		}()
+	}
+}
+
+// recordLoginFailure 根据登录流程返回的错误记录失败原因
+func (s *service) recordLoginFailure(ctx *gin.Context, user *model.User, device string, err error) {
+	s.recordLogin(ctx, user, device, "", loginFailureReason(err))
+}
+
+// unfamiliarLogin 用户以往成功登录过，但从未使用过这个设备，或从未在这个网段登录过
+// 首次登录没有可以比较的记录，不视为可疑
+func (s *service) unfamiliarLogin(ctx context.Context, record *model.LoginRecord) bool {
+	db := s.GetDB(ctx).Model(&model.LoginRecord{}).Where("userid = ? AND success = ?", record.UserId, true).Session(&gorm.Session{})
+	var total, knownDevice, knownRange int64
+	if err := db.Count(&total).Error; err != nil || total == 0 {
+		return false
+	}
+	if err := db.Where("device = ? AND user_agent = ?", record.Device, record.UserAgent).Count(&knownDevice).Error; err != nil {
+		return false
+	}
+	if err := db.Where("ip_range = ?", record.IpRange).Count(&knownRange).Error; err != nil {
+		return false
+	}
+	return knownDevice == 0 || knownRange == 0
+}
+
+// loginFailureReason 将登录流程返回的错误转换为登录记录中的失败原因
+func loginFailureReason(err error) string {
+	var e *exception.PersonalError
+	if !errors.As(err, &e) {
+		return enums.LOGIN_ERROR
+	}
+	switch e.Code {
+	case exception.ErrLoginFailed.Code:
+		return enums.LOGIN_BAD_CREDENTIALS
+	case exception.ErrLoginLocked.Code:
+		return enums.LOGIN_LOCKED
+	case exception.ErrCheckCode.Code:
+		return enums.LOGIN_CAPTCHA
+	case exception.ErrAccountBanned.Code:
+		return enums.LOGIN_BANNED
+	case exception.ErrAccountDeleted.Code:
+		return enums.LOGIN_DELETED
+	case exception.ErrEmailNotVerified.Code:
+		return enums.LOGIN_EMAIL_UNVERIFIED
+	case exception.ErrTwoFactorCode.Code:
+		return enums.LOGIN_TWO_FACTOR
+	default:
+		return enums.LOGIN_ERROR
+	}
+}
+
+// ipRange IP 所在的网段，IPv4 取 /24，IPv6 取 /48，用于判断是否在常用网络登录
+func ipRange(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
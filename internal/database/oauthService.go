@@ -0,0 +1,227 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/internal/oidc"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+)
+
+type OAuthService interface {
+	OAuthAuthorize(ctx context.Context, provider string, authorize request.OAuthAuthorize) (*types.OAuthAuthorize, error)
+	OAuthCallback(ctx *gin.Context, callback request.OAuthCallback) (*types.LoginResult, error)
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// OAuthAuthorize 开始第三方登录，生成 state、nonce 与 PKCE 参数并返回提供方的授权地址
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	provider string: 身份提供方名称，对应 OIDC_PROVIDERS 中的配置
+//	authorize request.OAuthAuthorize: 登录设备名称
+//
+// 返回值:
+//
+//	*types.OAuthAuthorize: 授权地址与 state
+//	error: 提供方不存在或无法获取提供方配置时返回的错误
+func (s *service) OAuthAuthorize(ctx context.Context, provider string, authorize request.OAuthAuthorize) (*types.OAuthAuthorize, error) {
+	p, ok := s.oidc[strings.ToLower(provider)]
+	if !ok {
+		return nil, exception.ErrNotFound
+	}
+	state, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+	authUrl, err := p.AuthCodeUrl(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Logger.Error().Err(err).Str("provider", p.Name).Msg("获取身份提供方配置失败")
+		return nil, exception.ErrOAuthFailed
+	}
+	data, err := json.Marshal(types.OAuthState{Provider: p.Name, Nonce: nonce, Verifier: verifier, Device: authorize.Device})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SetAndTime(ctx, defines.OIDC_STATE+state, string(data), defines.OIDC_STATE_TTL); err != nil {
+		log.Logger.Error().Err(err).Msg("保存授权状态失败")
+		return nil, err
+	}
+	return &types.OAuthAuthorize{AuthUrl: authUrl, State: state}, nil
+}
+
+// OAuthCallback 完成第三方登录
+// 使用授权码与 code_verifier 换取并校验 ID 令牌，按提供方与用户标识找到关联的用户；
+// 首次登录时创建新用户；邮箱已注册时，只有开启了 LinkByEmail 的提供方才会关联到该用户，否则登录失败。之后与密码登录一样签发令牌。
+func (s *service) OAuthCallback(ctx *gin.Context, callback request.OAuthCallback) (*types.LoginResult, error) {
+	// state 只能使用一次
+	value := s.GetValue(ctx, defines.OIDC_STATE+callback.State)
+	if value == "" {
+		return nil, exception.ErrOAuthFailed
+	}
+	_ = s.DelValue(ctx, defines.OIDC_STATE+callback.State)
+	var state types.OAuthState
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, exception.ErrOAuthFailed
+	}
+	p, ok := s.oidc[state.Provider]
+	if !ok {
+		return nil, exception.ErrOAuthFailed
+	}
+	claims, err := p.Exchange(ctx, callback.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		log.Logger.Error().Err(err).Str("provider", p.Name).Msg("第三方登录校验失败")
+		return nil, exception.ErrOAuthFailed
+	}
+	var user model.User
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		found, err := s.findOrCreateIdentityUser(ctx, p, claims)
+		if err != nil {
+			return err
+		}
+		user = *found
+		return s.checkBan(ctx, &user)
+	})
+	if err != nil {
+		if user.Email == "" {
+			user.Email = claims.Email
+		}
+		s.recordLoginFailure(ctx, &user, state.Device, err)
+		return nil, err
+	}
+	return s.loginResult(ctx, &user, state.Device, "")
+}
+
+// findOrCreateIdentityUser 查找外部身份关联的用户，没有关联时关联或创建用户，需在事务中调用
+func (s *service) findOrCreateIdentityUser(ctx context.Context, p *oidc.Provider, claims *oidc.IdClaims) (*model.User, error) {
+	var identity model.UserIdentity
+	err := s.GetDB(ctx).Model(&model.UserIdentity{}).Where("provider = ? AND subject = ?", p.Name, claims.Subject).First(&identity).Error
+	if err == nil {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", identity.UserId).First(&user).Error; err != nil {
+			// 关联的账号已注销
+			return nil, exception.ErrOAuthFailed
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Logger.Error().Err(err).Msg("查询外部身份失败")
+		return nil, err
+	}
+	var user model.User
+	// 只有配置为可信的提供方、且提供方确认过的邮箱才会关联到已有账号，避免通过外部账号接管他人账号
+	// 其余情况下邮箱已注册时，createIdentityUser 返回 ErrAlreadyExist
+	linked := p.LinkByEmail && claims.Email != "" && bool(claims.EmailVerified) &&
+		s.GetDB(ctx).Model(&model.User{}).Where("email = ?", claims.Email).First(&user).Error == nil
+	if !linked {
+		created, err := s.createIdentityUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	}
+	if err := s.GetDB(ctx).Create(&model.UserIdentity{
+		UserId:   user.Uuid,
+		Provider: p.Name,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("关联外部身份失败")
+		return nil, err
+	}
+	return &user, nil
+}
+
+// createIdentityUser 为首次通过第三方登录的用户创建账号
+// 邮箱是账号的唯一标识，提供方没有返回已验证的邮箱时无法创建账号；账号没有可用的密码，需要时可以通过忘记密码设置
+func (s *service) createIdentityUser(ctx context.Context, claims *oidc.IdClaims) (*model.User, error) {
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return nil, exception.ErrOAuthFailed
+	}
+	// 邮箱被宽限期内的已注销账号占用
+	var count int64
+	if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("email = ?", claims.Email).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count != 0 {
+		return nil, exception.ErrAlreadyExist
+	}
+	username, err := s.uniqueUsername(ctx, claims.PreferredUsername, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+	user := model.User{
+		Uuid:      uuid.New().String(),
+		Username:  username,
+		Nickname:  truncateRunes(claims.Name, 32),
+		Password:  password,
+		Email:     claims.Email,
+		EmailHash: utils.HashEmail(claims.Email),
+	}
+	if err := s.GetDB(ctx).Create(&user).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("创建用户失败")
+		return nil, err
+	}
+	return &user, nil
+}
+
+// uniqueUsername 根据外部身份的用户名或邮箱生成未被占用的用户名
+func (s *service) uniqueUsername(ctx context.Context, preferred, email string) (string, error) {
+	base := preferred
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+	base = truncateRunes(usernameInvalidChars.ReplaceAllString(base, ""), 24)
+	if base == "" {
+		base = "user"
+	}
+	username := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := s.GetDB(ctx).Unscoped().Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+		suffix, err := randomDigits(6)
+		if err != nil {
+			return "", err
+		}
+		username = base + "_" + suffix
+	}
+	return "", exception.ErrAlreadyExist
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) > length {
+		return string(runes[:length])
+	}
+	return value
+}
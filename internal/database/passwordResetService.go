@@ -0,0 +1,116 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/utils"
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"strings"
+)
+
+type PasswordResetService interface {
+	ForgotPassword(ctx context.Context, forgot request.PasswordForgot) error
+	ResetPassword(ctx context.Context, reset request.PasswordReset) error
+}
+
+// passwordResetUrl 重置密码页面地址，配置后邮件中会附带完整链接
+var passwordResetUrl = os.Getenv("PASSWORD_RESET_URL")
+
+// ForgotPassword 申请重置密码，向邮箱发送一次性的重置令牌
+// 无论邮箱是否存在都返回成功，避免被用来探测邮箱是否注册
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	forgot request.PasswordForgot: 邮箱
+//
+// 返回值:
+//
+//	error: 始终返回nil，发送失败只记录日志
+func (s *service) ForgotPassword(ctx context.Context, forgot request.PasswordForgot) error {
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("email = ?", forgot.Email).First(&user).Error; err != nil {
+		return nil
+	}
+	// 限制发送频率，防止向同一邮箱反复发信
+	if s.GetValue(ctx, defines.PASSWORD_RESET_SENT+user.Uuid) != "" {
+		return nil
+	}
+	resetToken, err := newSecureToken()
+	if err != nil {
+		return nil
+	}
+	// 令牌的格式为 用户ID.随机串，按用户保存随机串的哈希，新令牌生成后旧令牌随即失效
+	if err := s.SetAndTime(ctx, defines.PASSWORD_RESET+user.Uuid, hashToken(resetToken), defines.PASSWORD_RESET_TIMEOUT); err != nil {
+		log.Logger.Error().Err(err).Msg("保存重置密码令牌失败")
+		return nil
+	}
+	resetToken = user.Uuid + "." + resetToken
+	if err := s.SetAndTime(ctx, defines.PASSWORD_RESET_SENT+user.Uuid, "1", defines.EMAIL_RESEND_INTERVAL); err != nil {
+		log.Logger.Error().Err(err).Msg("设置发送间隔失败")
+	}
+	body := fmt.Sprintf("%s，你好：\n\n你的重置密码令牌是 %s，%d 分钟内有效且只能使用一次。\n",
+		user.Username, resetToken, defines.PASSWORD_RESET_TIMEOUT/60)
+	if passwordResetUrl != "" {
+		body += fmt.Sprintf("也可以直接打开链接重置密码：%s?token=%s\n", passwordResetUrl, resetToken)
+	}
+	body += "如果这不是你本人的操作，请忽略本邮件，你的密码不会被修改。"
+	// 在后台发送，已注册邮箱的响应时间与未注册的相同
+	s.sendMail(user.Email, "重置密码", body)
+	return nil
+}
+
+// ResetPassword 使用重置令牌设置新密码
+// 令牌使用后立即失效；重置成功后注销该用户所有设备上的会话
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	reset request.PasswordReset: 重置令牌与新密码
+//
+// 返回值:
+//
+//	error: 令牌无效或已过期时返回 ErrInvalidToken
+func (s *service) ResetPassword(ctx context.Context, reset request.PasswordReset) error {
+	userId, secret, ok := strings.Cut(reset.Token, ".")
+	if !ok || userId == "" || secret == "" {
+		return exception.ErrInvalidToken
+	}
+	// 比较与删除原子地完成，同一个令牌并发使用时只有一个请求能成功
+	consumed, err := s.DelIfValue(ctx, defines.PASSWORD_RESET+userId, hashToken(secret))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return exception.ErrInvalidToken
+	}
+	password, err := utils.GernerateHashPassword(reset.Password)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("计算密码哈希失败")
+		return err
+	}
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		// 能收到重置邮件说明用户拥有该邮箱，同时视为完成了邮箱验证
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", userId).Updates(map[string]interface{}{
+			"password":       password,
+			"email_verified": true,
+		}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("重置密码失败")
+			return err
+		}
+		// 所有会话都会被注销，已封禁的用户不会被改回退出状态
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ? AND status <> ?", userId, enums.Forbid).Update("status", enums.LogOut).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新用户状态失败")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.revokeSessions(ctx, userId, "")
+}
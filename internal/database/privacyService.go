@@ -0,0 +1,218 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"strings"
+)
+
+type PrivacyService interface {
+	GetPrivacy(ctx context.Context, claims *types.GIClaims) (*types.Privacy, error)
+	UpdatePrivacy(ctx context.Context, claims *types.GIClaims, privacy request.Privacy) error
+	GetFriendQuestion(ctx context.Context, friendInfo request.FriendRequest) (string, error)
+}
+
+// defaultPrivacy 用户未设置隐私选项时使用的默认值：可被搜索、好友申请需要验证、所有人可见最后在线时间
+func defaultPrivacy(userId string) model.UserPrivacy {
+	return model.UserPrivacy{
+		UserId:             userId,
+		SearchByEmail:      true,
+		SearchByUsername:   true,
+		FriendPolicy:       int8(enums.FRIEND_VERIFY),
+		LastSeenVisibility: int8(enums.LAST_SEEN_EVERYONE),
+	}
+}
+
+// getPrivacy 查询用户的隐私设置，不存在时返回默认设置
+func (s *service) getPrivacy(ctx context.Context, userId string) (model.UserPrivacy, error) {
+	var privacy model.UserPrivacy
+	if err := s.GetDB(ctx).Model(&model.UserPrivacy{}).Where("userid = ?", userId).First(&privacy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultPrivacy(userId), nil
+		}
+		log.Logger.Error().Err(err).Msg("查询隐私设置失败")
+		return privacy, err
+	}
+	return privacy, nil
+}
+
+// GetPrivacy 获取当前用户的隐私设置
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//
+// 返回值:
+//
+//	*types.Privacy: 隐私设置，不包含验证问题的答案
+//	error: 查询失败时返回的错误
+func (s *service) GetPrivacy(ctx context.Context, claims *types.GIClaims) (*types.Privacy, error) {
+	privacy, err := s.getPrivacy(ctx, claims.UserId)
+	if err != nil {
+		return nil, exception.ErrNotFound
+	}
+	return &types.Privacy{
+		SearchByEmail:      privacy.SearchByEmail,
+		SearchByUsername:   privacy.SearchByUsername,
+		FriendPolicy:       privacy.FriendPolicy,
+		Question:           privacy.Question,
+		LastSeenVisibility: privacy.LastSeenVisibility,
+	}, nil
+}
+
+// UpdatePrivacy 保存当前用户的隐私设置，记录不存在时创建
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	privacy request.Privacy: 新的隐私设置
+//
+// 返回值:
+//
+//	error: 保存失败时返回的错误
+func (s *service) UpdatePrivacy(ctx context.Context, claims *types.GIClaims, privacy request.Privacy) error {
+	return s.Transaction(ctx, func(ctx context.Context) error {
+		current, err := s.getPrivacy(ctx, claims.UserId)
+		if err != nil {
+			return err
+		}
+		current.SearchByEmail = privacy.SearchByEmail
+		current.SearchByUsername = privacy.SearchByUsername
+		current.FriendPolicy = privacy.FriendPolicy
+		current.LastSeenVisibility = privacy.LastSeenVisibility
+		// 只有“回答问题”策略才保留问题与答案
+		if privacy.FriendPolicy == int8(enums.FRIEND_QUESTION) {
+			current.Question = strings.TrimSpace(privacy.Question)
+			current.Answer = normalizeAnswer(privacy.Answer)
+		} else {
+			current.Question = ""
+			current.Answer = ""
+		}
+		if err := s.GetDB(ctx).Save(&current).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新隐私设置失败")
+			return err
+		}
+		return nil
+	})
+}
+
+// GetFriendQuestion 获取添加对方为好友时需要回答的问题
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	friendInfo request.FriendRequest: 对方的用户名或邮箱
+//
+// 返回值:
+//
+//	string: 验证问题，对方未设置问题时为空字符串
+//	error: 用户不存在或不允许被搜索时返回的错误
+func (s *service) GetFriendQuestion(ctx context.Context, friendInfo request.FriendRequest) (string, error) {
+	friend, err := s.findSearchableUser(ctx, friendInfo.FriendInfo)
+	if err != nil {
+		return "", err
+	}
+	privacy, err := s.getPrivacy(ctx, friend.Uuid)
+	if err != nil {
+		return "", exception.ErrNotFound
+	}
+	if privacy.FriendPolicy != int8(enums.FRIEND_QUESTION) {
+		return "", nil
+	}
+	return privacy.Question, nil
+}
+
+// findSearchableUser 按用户名或邮箱查找用户，并遵守对方“允许通过邮箱/用户名查找”的设置
+func (s *service) findSearchableUser(ctx context.Context, info string) (*model.User, error) {
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("username = ?", info).Or("email = ?", info).First(&user).Error; err != nil {
+		return nil, exception.ErrNotFound
+	}
+	privacy, err := s.getPrivacy(ctx, user.Uuid)
+	if err != nil {
+		return nil, exception.ErrNotFound
+	}
+	if (user.Email == info && !privacy.SearchByEmail) || (user.Username == info && !privacy.SearchByUsername) {
+		return nil, exception.ErrNotFound
+	}
+	return &user, nil
+}
+
+// checkFriendPolicy 根据对方的好友申请策略判断申请是否可以发起
+// 返回值 autoAccept 为 true 时表示对方设置了自动通过
+func checkFriendPolicy(privacy model.UserPrivacy, answer string) (autoAccept bool, err error) {
+	switch enums.FriendPolicyEnum(privacy.FriendPolicy) {
+	case enums.FRIEND_REFUSE:
+		return false, exception.ErrFriendRefused
+	case enums.FRIEND_QUESTION:
+		if normalizeAnswer(answer) != privacy.Answer {
+			return false, exception.ErrFriendAnswer
+		}
+		return false, nil
+	case enums.FRIEND_AUTO_ACCEPT:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// normalizeAnswer 忽略首尾空白与大小写比较验证答案
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.TrimSpace(answer))
+}
+
+// hideLastSeen 根据被查看用户的设置隐藏其最后在线时间
+// viewerId 为查看者的用户ID，profiles 中的资料会被原地修改
+func (s *service) hideLastSeen(ctx context.Context, viewerId string, profiles ...*types.PublicProfile) {
+	if len(profiles) == 0 {
+		return
+	}
+	userIds := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		userIds = append(userIds, profile.Uuid)
+	}
+	var privacies []model.UserPrivacy
+	if err := s.GetDB(ctx).Model(&model.UserPrivacy{}).
+		Where("userid IN ? AND last_seen_visibility <> ?", userIds, enums.LAST_SEEN_EVERYONE).
+		Find(&privacies).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询隐私设置失败")
+		// 查询失败时宁可全部隐藏
+		for _, profile := range profiles {
+			profile.LastSeen = nil
+		}
+		return
+	}
+	if len(privacies) == 0 {
+		return
+	}
+	visibility := make(map[string]enums.LastSeenEnum, len(privacies))
+	for _, privacy := range privacies {
+		visibility[privacy.UserId] = enums.LastSeenEnum(privacy.LastSeenVisibility)
+	}
+	var friendIds []string
+	if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+		Where("userid = ? AND status = ?", viewerId, enums.IS_FRIEND).
+		Pluck("friendid", &friendIds).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询好友失败")
+	}
+	isFriend := make(map[string]bool, len(friendIds))
+	for _, friendId := range friendIds {
+		isFriend[friendId] = true
+	}
+	for _, profile := range profiles {
+		switch visibility[profile.Uuid] {
+		case enums.LAST_SEEN_NOBODY:
+			profile.LastSeen = nil
+		case enums.LAST_SEEN_FRIENDS:
+			if !isFriend[profile.Uuid] {
+				profile.LastSeen = nil
+			}
+		}
+	}
+}
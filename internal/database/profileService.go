@@ -0,0 +1,179 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"strings"
+)
+
+type ProfileService interface {
+	UpdateProfile(ctx context.Context, claims *types.GIClaims, profile request.ProfileUpdate) error
+	ChangePassword(ctx context.Context, claims *types.GIClaims, change request.PasswordChange) error
+	UpdateAvatar(ctx context.Context, claims *types.GIClaims, data []byte, crop request.AvatarCrop) (string, error)
+	GetAvatarUrl(ctx context.Context, objectName string) (string, error)
+}
+
+// UpdateProfile 修改用户名与昵称，字段为空时保持不变
+// 资料变化后为所有好友记录一条通讯录变更，好友下次增量同步时即可拿到新资料
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	profile request.ProfileUpdate: 新的用户名与昵称
+//
+// 返回值:
+//
+//	error: 用户名已被占用或更新失败时返回的错误
+func (s *service) UpdateProfile(ctx context.Context, claims *types.GIClaims, profile request.ProfileUpdate) error {
+	return s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		updates := make(map[string]interface{})
+		username := strings.TrimSpace(profile.Username)
+		if username != "" && username != user.Username {
+			var count int64
+			if err := s.GetDB(ctx).Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+				log.Logger.Error().Err(err).Msg("查询用户失败")
+				return err
+			}
+			if count > 0 {
+				return exception.ErrAlreadyExist
+			}
+			updates["username"] = username
+		}
+		nickname := strings.TrimSpace(profile.Nickname)
+		if nickname != "" && nickname != user.Nickname {
+			updates["nickname"] = nickname
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := s.GetDB(ctx).Model(&user).Updates(updates).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新用户资料失败")
+			return err
+		}
+		return s.notifyProfileChange(ctx, claims.UserId)
+	})
+}
+
+// ChangePassword 修改密码，需要验证原密码，成功后注销当前设备以外的所有会话
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	change request.PasswordChange: 原密码与新密码
+//
+// 返回值:
+//
+//	error: 原密码错误或更新失败时返回的错误
+func (s *service) ChangePassword(ctx context.Context, claims *types.GIClaims, change request.PasswordChange) error {
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		if !utils.CompareHashPassword(user.Password, change.OldPassword) {
+			return exception.ErrPassword
+		}
+		password, err := utils.GernerateHashPassword(change.NewPassword)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("计算密码哈希失败")
+			return err
+		}
+		if err := s.GetDB(ctx).Model(&user).Update("password", password).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("修改密码失败")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.revokeSessions(ctx, claims.UserId, claims.SessionId)
+}
+
+// UpdateAvatar 裁剪并缩放上传的图片，保存到 MinIO 后替换用户头像，旧头像会被删除
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	data []byte: 上传的原始图片
+//	crop request.AvatarCrop: 裁剪区域，Size 为0时从中心裁剪
+//
+// 返回值:
+//
+//	string: 新头像的对象名称
+//	error: 图片无法解析或上传失败时返回的错误
+func (s *service) UpdateAvatar(ctx context.Context, claims *types.GIClaims, data []byte, crop request.AvatarCrop) (string, error) {
+	avatar, err := utils.CropAvatar(data, crop.X, crop.Y, crop.Size)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("头像图片处理失败")
+		return "", exception.ErrUploadFile
+	}
+	// 每次上传使用新的对象名，避免客户端缓存旧头像
+	objectName := defines.AVATAR_PREFIX + claims.UserId + "/" + uuid.New().String() + ".png"
+	if err := s.minClient.PutFile(ctx, objectName, avatar, "image/png"); err != nil {
+		return "", exception.ErrUploadFile
+	}
+	var oldAvatar string
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		oldAvatar = user.Avatar
+		if err := s.GetDB(ctx).Model(&user).Update("avatar", objectName).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新头像失败")
+			return err
+		}
+		return s.notifyProfileChange(ctx, claims.UserId)
+	})
+	if err != nil {
+		_ = s.minClient.DeleteFiles(ctx, false, objectName)
+		return "", err
+	}
+	if strings.HasPrefix(oldAvatar, defines.AVATAR_PREFIX) {
+		_ = s.minClient.DeleteFiles(ctx, false, oldAvatar)
+	}
+	return objectName, nil
+}
+
+// GetAvatarUrl 获取头像的预签名下载地址，只允许访问头像目录下的对象
+func (s *service) GetAvatarUrl(ctx context.Context, objectName string) (string, error) {
+	if !strings.HasPrefix(objectName, defines.AVATAR_PREFIX) || strings.Contains(objectName, "..") {
+		return "", exception.ErrNotFound
+	}
+	url, err := s.minClient.GetFileSign(ctx, objectName)
+	if err != nil {
+		return "", exception.ErrFileUrl
+	}
+	return url, nil
+}
+
+// notifyProfileChange 为当前用户的所有好友记录资料变更，按好友ID的顺序锁定通讯录版本，避免并发修改资料时互相等待
+func (s *service) notifyProfileChange(ctx context.Context, userId string) error {
+	var friendIds []string
+	if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+		Where("userid = ? AND status = ?", userId, enums.IS_FRIEND).
+		Order("friendid").
+		Pluck("friendid", &friendIds).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询好友失败")
+		return err
+	}
+	for _, friendId := range friendIds {
+		if err := s.recordContactChange(ctx, enums.CONTACT_UPDATE, friendId, userId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
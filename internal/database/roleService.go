@@ -0,0 +1,274 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+)
+
+type RoleService interface {
+	SeedRoles(ctx context.Context) error
+	PromoteAdmins(ctx context.Context) error
+	UserPermissions(ctx context.Context, userId string) ([]string, error)
+	ListRoles(ctx context.Context) ([]types.RoleInfo, error)
+	SaveRole(ctx context.Context, claims *types.GIClaims, save request.RoleSave) error
+	AssignRole(ctx context.Context, claims *types.GIClaims, assign request.RoleAssign) error
+	RevokeRole(ctx context.Context, claims *types.GIClaims, revoke request.RoleAssign) error
+}
+
+// SeedRoles 将代码中定义的权限同步到权限表，并确保内置的管理员角色拥有全部权限，启动时执行一次
+func (s *service) SeedRoles(ctx context.Context) error {
+	return s.Transaction(ctx, func(ctx context.Context) error {
+		permissions := make([]model.Permission, 0, len(enums.Permissions))
+		for code, description := range enums.Permissions {
+			permission := model.Permission{Code: code}
+			if err := s.GetDB(ctx).Where("code = ?", code).Attrs(model.Permission{Description: description}).FirstOrCreate(&permission).Error; err != nil {
+				return err
+			}
+			permissions = append(permissions, permission)
+		}
+		role := model.Role{Name: enums.ROLE_ADMIN}
+		if err := s.GetDB(ctx).Where("name = ?", enums.ROLE_ADMIN).Attrs(model.Role{Description: "管理员"}).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		return s.GetDB(ctx).Model(&role).Association("Permissions").Append(permissions)
+	})
+}
+
+// PromoteAdmins 按 ADMIN 环境变量同步本地账号的管理员角色，启动时执行一次
+// 列表中的邮箱对应的账号获得管理员角色，不在列表中的本地账号的管理员角色会被撤销，因此修改 ADMIN 后需要重启服务才会生效。
+// 目录账号的管理员角色由 LDAP 组决定，不受 ADMIN 影响
+func (s *service) PromoteAdmins(ctx context.Context) error {
+	emails := make([]string, 0)
+	for _, email := range strings.Split(os.Getenv("ADMIN"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	var role model.Role
+	if err := s.GetDB(ctx).Model(&model.Role{}).Where("name = ?", enums.ROLE_ADMIN).First(&role).Error; err != nil {
+		return err
+	}
+	directoryUsers := s.GetDB(ctx).Model(&model.UserIdentity{}).Select("userid").Where("provider = ?", defines.LDAP_PROVIDER)
+	var revoked []string
+	query := s.GetDB(ctx).Model(&model.UserRole{}).Where("role_id = ? AND userid NOT IN (?)", role.ID, directoryUsers)
+	if len(emails) > 0 {
+		query = query.Where("userid NOT IN (?)", s.GetDB(ctx).Model(&model.User{}).Select("uuid").Where("email IN ?", emails))
+	}
+	if err := query.Pluck("userid", &revoked).Error; err != nil {
+		return err
+	}
+	for _, userId := range revoked {
+		if err := s.setRole(ctx, userId, enums.ROLE_ADMIN, false); err != nil {
+			return err
+		}
+	}
+	if len(revoked) > 0 {
+		log.Logger.Info().Strs("userIds", revoked).Msg("撤销不在 ADMIN 中的账号的管理员角色")
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+	var userIds []string
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("email IN ? AND uuid NOT IN (?)", emails, directoryUsers).Pluck("uuid", &userIds).Error; err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		if err := s.setRole(ctx, userId, enums.ROLE_ADMIN, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UserPermissions 返回用户通过所有角色获得的权限，结果在 Valkey 中缓存 USER_PERMISSIONS_TTL 秒
+// 角色或角色的权限变化时会清除相关用户的缓存
+func (s *service) UserPermissions(ctx context.Context, userId string) ([]string, error) {
+	if value := s.GetValue(ctx, defines.USER_PERMISSIONS+userId); value != "" {
+		var permissions []string
+		if err := json.Unmarshal([]byte(value), &permissions); err == nil {
+			return permissions, nil
+		}
+	}
+	// 使用模型构造子查询，表名随 DB_SINGULAR_TABLE 变化，只有关联表的名称是固定的
+	roleIds := s.GetDB(ctx).Model(&model.Role{}).Select("id").
+		Where("id IN (?)", s.GetDB(ctx).Model(&model.UserRole{}).Select("role_id").Where("userid = ?", userId))
+	permissionIds := s.GetDB(ctx).Table("role_permissions").Select("permission_id").Where("role_id IN (?)", roleIds)
+	permissions := make([]string, 0)
+	if err := s.GetDB(ctx).Model(&model.Permission{}).Where("id IN (?)", permissionIds).Pluck("code", &permissions).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询用户权限失败")
+		return nil, err
+	}
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SetAndTime(ctx, defines.USER_PERMISSIONS+userId, string(data), defines.USER_PERMISSIONS_TTL); err != nil {
+		log.Logger.Error().Err(err).Msg("缓存用户权限失败")
+	}
+	return permissions, nil
+}
+
+// ListRoles 列出所有角色及其权限
+func (s *service) ListRoles(ctx context.Context) ([]types.RoleInfo, error) {
+	var roles []model.Role
+	if err := s.GetDB(ctx).Model(&model.Role{}).Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询角色失败")
+		return nil, err
+	}
+	infos := make([]types.RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		info := types.RoleInfo{Name: role.Name, Description: role.Description, Permissions: make([]string, 0, len(role.Permissions))}
+		for _, permission := range role.Permissions {
+			info.Permissions = append(info.Permissions, permission.Code)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// SaveRole 创建角色或替换已有角色的说明与权限
+// 内置的管理员角色在启动时同步，不能通过接口修改
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 管理员的令牌声明
+//	save request.RoleSave: 角色名称、说明与权限标识列表
+//
+// 返回值:
+//
+//	error: 修改内置角色或包含未知的权限标识时返回的错误
+func (s *service) SaveRole(ctx context.Context, claims *types.GIClaims, save request.RoleSave) error {
+	if save.Name == enums.ROLE_ADMIN {
+		return exception.ErrBadRequest
+	}
+	var userIds []string
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		permissions := make([]model.Permission, 0, len(save.Permissions))
+		if len(save.Permissions) > 0 {
+			if err := s.GetDB(ctx).Model(&model.Permission{}).Where("code IN ?", save.Permissions).Find(&permissions).Error; err != nil {
+				return err
+			}
+		}
+		if len(permissions) != len(uniqueStrings(save.Permissions)) {
+			return exception.ErrBadRequest
+		}
+		role := model.Role{Name: save.Name}
+		if err := s.GetDB(ctx).Where("name = ?", save.Name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+		if err := s.GetDB(ctx).Model(&role).Update("description", save.Description).Error; err != nil {
+			return err
+		}
+		if err := s.GetDB(ctx).Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		return s.GetDB(ctx).Model(&model.UserRole{}).Where("role_id = ?", role.ID).Pluck("userid", &userIds).Error
+	})
+	if err != nil {
+		if !errors.Is(err, exception.ErrBadRequest) {
+			log.Logger.Error().Err(err).Msg("保存角色失败")
+		}
+		return err
+	}
+	s.clearPermissions(ctx, userIds...)
+	log.Logger.Info().Str("admin", claims.UserId).Str("role", save.Name).Strs("permissions", save.Permissions).Msg("保存角色")
+	return nil
+}
+
+// AssignRole 为用户授予角色，用户已拥有该角色时不做任何操作
+func (s *service) AssignRole(ctx context.Context, claims *types.GIClaims, assign request.RoleAssign) error {
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var count int64
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", assign.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return exception.ErrNotFound
+		}
+		return s.setRole(ctx, assign.UserId, assign.Role, true)
+	})
+	if err != nil {
+		return err
+	}
+	log.Logger.Info().Str("admin", claims.UserId).Str("userId", assign.UserId).Str("role", assign.Role).Msg("授予角色")
+	return nil
+}
+
+// RevokeRole 撤销用户的角色，管理员不能撤销自己的管理员角色
+func (s *service) RevokeRole(ctx context.Context, claims *types.GIClaims, revoke request.RoleAssign) error {
+	if revoke.UserId == claims.UserId && revoke.Role == enums.ROLE_ADMIN {
+		return exception.ErrBadRequest
+	}
+	if err := s.setRole(ctx, revoke.UserId, revoke.Role, false); err != nil {
+		return err
+	}
+	log.Logger.Info().Str("admin", claims.UserId).Str("userId", revoke.UserId).Str("role", revoke.Role).Msg("撤销角色")
+	return nil
+}
+
+// setRole 授予或撤销用户的角色并清除其权限缓存，角色不存在时返回 ErrNotFound
+func (s *service) setRole(ctx context.Context, userId, roleName string, granted bool) error {
+	var role model.Role
+	if err := s.GetDB(ctx).Model(&model.Role{}).Where("name = ?", roleName).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return exception.ErrNotFound
+		}
+		return err
+	}
+	var count int64
+	if err := s.GetDB(ctx).Model(&model.UserRole{}).Where("userid = ? AND role_id = ?", userId, role.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	switch {
+	case granted && count == 0:
+		if err := s.GetDB(ctx).Create(&model.UserRole{UserId: userId, RoleId: role.ID}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("授予角色失败")
+			return err
+		}
+	case !granted && count != 0:
+		if err := s.GetDB(ctx).Unscoped().Where("userid = ? AND role_id = ?", userId, role.ID).Delete(&model.UserRole{}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("撤销角色失败")
+			return err
+		}
+	default:
+		return nil
+	}
+	s.clearPermissions(ctx, userId)
+	return nil
+}
+
+// clearPermissions 清除用户的权限缓存，下一次请求时重新加载
+func (s *service) clearPermissions(ctx context.Context, userIds ...string) {
+	if len(userIds) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		keys = append(keys, defines.USER_PERMISSIONS+userId)
+	}
+	_ = s.DelValue(ctx, keys...)
+}
+
+// uniqueStrings 去除重复的字符串，保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
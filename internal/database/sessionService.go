@@ -0,0 +1,317 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/types"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SessionService interface {
+	RefreshToken(ctx *gin.Context, refresh request.RefreshToken) (*types.TokenPair, error)
+	CheckSession(ctx context.Context, claims *types.GIClaims) error
+	ListSessions(ctx context.Context, claims *types.GIClaims) ([]types.SessionInfo, error)
+	RevokeSession(ctx context.Context, claims *types.GIClaims, revoke request.SessionRevoke) error
+	RevokeOtherSessions(ctx context.Context, claims *types.GIClaims) error
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌，并轮换刷新令牌
+// 已经轮换过的刷新令牌再次出现时视为令牌泄露，会立即注销对应的会话
+// 参数:
+//
+//	ctx *gin.Context: Gin框架的上下文
+//	refresh request.RefreshToken: 客户端保存的刷新令牌
+//
+// 返回值:
+//
+//	*types.TokenPair: 新的访问令牌与刷新令牌
+//	error: 刷新令牌无效、已过期或被重复使用时返回的错误
+func (s *service) RefreshToken(ctx *gin.Context, refresh request.RefreshToken) (*types.TokenPair, error) {
+	// 刷新令牌的格式为 用户ID.会话ID.随机串
+	parts := strings.Split(refresh.RefreshToken, ".")
+	if len(parts) != 3 {
+		return nil, exception.ErrInvalidToken
+	}
+	userId, sessionId, secret := parts[0], parts[1], parts[2]
+	hash := hashToken(secret)
+	session, err := s.getSession(ctx, userId, sessionId)
+	if err != nil {
+		return nil, exception.ErrInvalidToken
+	}
+	if session.RefreshHash != hash {
+		// 旧的刷新令牌被再次使用，说明令牌可能已经泄露，注销整个会话
+		if s.GetValue(ctx, defines.REFRESH_USED+hash) == sessionId {
+			log.Logger.Warn().Str("userId", userId).Str("sessionId", sessionId).Msg("检测到刷新令牌重复使用")
+			_ = s.deleteSession(ctx, userId, sessionId)
+			return nil, exception.ErrRefreshReused
+		}
+		return nil, exception.ErrInvalidToken
+	}
+	// 先原子地占用这个刷新令牌，同时到达的请求只有一个能继续，其余的按重复使用处理
+	claimed, err := s.SetNxAndTime(ctx, defines.REFRESH_USED+hash, sessionId, defines.USER_TOKEN)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("记录刷新令牌失败")
+		return nil, err
+	}
+	if !claimed {
+		log.Logger.Warn().Str("userId", userId).Str("sessionId", sessionId).Msg("检测到刷新令牌重复使用")
+		_ = s.deleteSession(ctx, userId, sessionId)
+		return nil, exception.ErrRefreshReused
+	}
+	var user model.User
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", userId).First(&user).Error; err != nil {
+		return nil, exception.ErrNotFound
+	}
+	if user.Status == int8(enums.Forbid) {
+		_ = s.deleteSession(ctx, userId, sessionId)
+		return nil, banError(&user)
+	}
+	secret, err = newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshHash = hashToken(secret)
+	session.LastSeen = time.Now()
+	session.Ip = ctx.ClientIP()
+	session.UserAgent = ctx.Request.UserAgent()
+	if err := s.saveSession(ctx, session, false); err != nil {
+		return nil, err
+	}
+	return issueTokens(session, &user, secret), nil
+}
+
+// CheckSession 检查访问令牌对应的会话是否仍然有效，并记录会话的最后活跃时间
+// 由 JWT 中间件在每次请求时调用，会话被注销后访问令牌立即失效
+func (s *service) CheckSession(ctx context.Context, claims *types.GIClaims) error {
+	if len(claims.UserId) == 0 || len(claims.SessionId) == 0 {
+		return exception.ErrTokenEmpty
+	}
+	if s.GetValue(ctx, sessionKey(claims.UserId, claims.SessionId)) == "" {
+		return exception.ErrLoginTimeout
+	}
+	// 封禁之前签发的访问令牌在过期之前仍然有效，发现封禁时一并注销该用户的所有会话
+	if err := s.CheckBanned(ctx, claims.UserId); err != nil {
+		_ = s.revokeSessions(ctx, claims.UserId, "")
+		return err
+	}
+	// 活跃时间单独保存，避免与刷新令牌的轮换同时改写会话
+	if err := s.SetAndTime(ctx, defines.SESSION_SEEN+claims.UserId+":"+claims.SessionId, strconv.FormatInt(time.Now().Unix(), 10), defines.USER_TOKEN); err != nil {
+		log.Logger.Error().Err(err).Msg("记录会话活跃时间失败")
+	}
+	return nil
+}
+
+// ListSessions 列出当前用户所有有效的设备会话
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//
+// 返回值:
+//
+//	[]types.SessionInfo: 按最后活跃时间降序排列的会话列表
+//	error: 查询失败时返回的错误
+func (s *service) ListSessions(ctx context.Context, claims *types.GIClaims) ([]types.SessionInfo, error) {
+	sessions := make([]types.SessionInfo, 0)
+	for _, sessionId := range s.GetSetMembers(ctx, defines.USER_SESSIONS+claims.UserId) {
+		session, err := s.getSession(ctx, claims.UserId, sessionId)
+		if err != nil {
+			// 会话已过期，顺便从集合中清理
+			_ = s.RemoveFromSet(ctx, defines.USER_SESSIONS+claims.UserId, sessionId)
+			continue
+		}
+		info := types.SessionInfo{
+			SessionId: session.SessionId,
+			Device:    session.Device,
+			Ip:        session.Ip,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			LastSeen:  session.LastSeen,
+			Current:   session.SessionId == claims.SessionId,
+		}
+		if seen, err := strconv.ParseInt(s.GetValue(ctx, defines.SESSION_SEEN+claims.UserId+":"+sessionId), 10, 64); err == nil && seen > info.LastSeen.Unix() {
+			info.LastSeen = time.Unix(seen, 0)
+		}
+		sessions = append(sessions, info)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 注销当前用户的指定会话
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	revoke request.SessionRevoke: 要注销的会话ID
+//
+// 返回值:
+//
+//	error: 会话不存在或注销失败时返回的错误
+func (s *service) RevokeSession(ctx context.Context, claims *types.GIClaims, revoke request.SessionRevoke) error {
+	if _, err := s.getSession(ctx, claims.UserId, revoke.SessionId); err != nil {
+		return exception.ErrNotFound
+	}
+	return s.deleteSession(ctx, claims.UserId, revoke.SessionId)
+}
+
+// RevokeOtherSessions 注销当前用户除当前设备以外的所有会话
+func (s *service) RevokeOtherSessions(ctx context.Context, claims *types.GIClaims) error {
+	return s.revokeSessions(ctx, claims.UserId, claims.SessionId)
+}
+
+// revokeSessions 注销用户的所有会话，exceptSessionId 不为空时保留该会话
+func (s *service) revokeSessions(ctx context.Context, userId, exceptSessionId string) error {
+	for _, sessionId := range s.GetSetMembers(ctx, defines.USER_SESSIONS+userId) {
+		if sessionId == exceptSessionId {
+			continue
+		}
+		if err := s.deleteSession(ctx, userId, sessionId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSession 为一次成功的登录创建新的设备会话并签发令牌
+func (s *service) createSession(ctx *gin.Context, user *model.User, device string) (*types.TokenPair, error) {
+	secret, err := newSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &types.Session{
+		SessionId:   uuid.New().String(),
+		UserId:      user.Uuid,
+		Device:      device,
+		Ip:          ctx.ClientIP(),
+		UserAgent:   ctx.Request.UserAgent(),
+		CreatedAt:   now,
+		LastSeen:    now,
+		RefreshHash: hashToken(secret),
+	}
+	if err := s.saveSession(ctx, session, true); err != nil {
+		return nil, err
+	}
+	return issueTokens(session, user, secret), nil
+}
+
+// getSession 读取用户的指定会话
+func (s *service) getSession(ctx context.Context, userId, sessionId string) (*types.Session, error) {
+	value := s.GetValue(ctx, sessionKey(userId, sessionId))
+	if value == "" {
+		return nil, exception.ErrLoginTimeout
+	}
+	var session types.Session
+	if err := json.Unmarshal([]byte(value), &session); err != nil {
+		log.Logger.Error().Err(err).Msg("会话解析失败")
+		return nil, exception.ErrLoginTimeout
+	}
+	return &session, nil
+}
+
+// saveSession 保存会话，每次保存都会刷新会话与用户会话集合的有效期
+// 集合的有效期与会话同时刷新，持续刷新令牌的会话不会从集合中消失，注销所有会话时不会遗漏。
+// create 为 false 时只更新仍然存在的会话，轮换令牌期间会话被注销时不会被重新写回
+func (s *service) saveSession(ctx context.Context, session *types.Session, create bool) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	key := sessionKey(session.UserId, session.SessionId)
+	if create {
+		err = s.SetAndTime(ctx, key, string(data), defines.USER_TOKEN)
+	} else if updated, setErr := s.SetXxAndTime(ctx, key, string(data), defines.USER_TOKEN); setErr != nil {
+		err = setErr
+	} else if !updated {
+		return exception.ErrLoginTimeout
+	}
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("保存会话失败")
+		return err
+	}
+	if err := s.AddToSetAndTime(ctx, defines.USER_SESSIONS+session.UserId, session.SessionId, defines.USER_TOKEN); err != nil {
+		log.Logger.Error().Err(err).Msg("记录用户会话失败")
+		return err
+	}
+	return nil
+}
+
+// deleteSession 删除会话，会话对应的访问令牌随即失效
+// 同时在 SESSION_REVOKED 频道发布“用户ID:会话ID”，实时连接服务订阅后关闭该会话的连接
+func (s *service) deleteSession(ctx context.Context, userId, sessionId string) error {
+	if err := s.DelValue(ctx, sessionKey(userId, sessionId), defines.SESSION_SEEN+userId+":"+sessionId); err != nil {
+		return err
+	}
+	if err := s.RemoveFromSet(ctx, defines.USER_SESSIONS+userId, sessionId); err != nil {
+		return err
+	}
+	if err := s.Publish(ctx, defines.SESSION_REVOKED, userId+":"+sessionId); err != nil {
+		log.Logger.Error().Err(err).Msg("发布会话注销通知失败")
+	}
+	return nil
+}
+
+// sessionKey 会话在 Valkey 中的键
+func sessionKey(userId, sessionId string) string {
+	return defines.USER_TOKEN_KEY + userId + ":" + sessionId
+}
+
+// issueTokens 为会话签发短期访问令牌，secret 为未哈希的刷新令牌随机串
+// 访问令牌的受众为 ACCESS_AUDIENCE，与同一密钥签发的邀请令牌区分开，邀请令牌不能用来访问接口
+func issueTokens(session *types.Session, user *model.User, secret string) *types.TokenPair {
+	now := time.Now()
+	expiresAt := now.Add(time.Minute * defines.TOKEN_EXPIRE)
+	claims := types.GIClaims{
+		UserId:    user.Uuid,
+		SessionId: session.SessionId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    defines.TOKEN_ISSUER,
+			Subject:   user.Uuid,
+			Audience:  jwt.ClaimStrings{defines.ACCESS_AUDIENCE},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return &types.TokenPair{
+		SessionId:    session.SessionId,
+		AccessToken:  token.GernerateToken(claims),
+		RefreshToken: user.Uuid + "." + session.SessionId + "." + secret,
+		ExpiresAt:    expiresAt,
+	}
+}
+
+// newSecureToken 生成32字节的随机令牌，用于刷新令牌、重置密码令牌等
+func newSecureToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Logger.Error().Err(err).Msg("生成随机令牌失败")
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 随机令牌只以 SHA-256 哈希形式保存，泄露存储内容也无法还原令牌
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"Gin-IM/internal/model"
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/types"
+	"Gin-IM/pkg/utils"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type TwoFactorService interface {
+	SetupTwoFactor(ctx context.Context, claims *types.GIClaims) (*types.TwoFactorSetup, error)
+	EnableTwoFactor(ctx context.Context, claims *types.GIClaims, confirm request.TwoFactorCode) ([]string, error)
+	DisableTwoFactor(ctx context.Context, claims *types.GIClaims, disable request.TwoFactorDisable) error
+	RegenerateRecoveryCodes(ctx context.Context, claims *types.GIClaims, confirm request.TwoFactorCode) ([]string, error)
+	VerifyTwoFactorLogin(ctx *gin.Context, verify request.TwoFactorLogin) (*types.TokenPair, error)
+}
+
+// loginChallenge 密码验证通过、等待两步验证的登录请求
+type loginChallenge struct {
+	UserId   string `json:"userId"`
+	Device   string `json:"device"`
+	DeviceId string `json:"deviceId"`
+}
+
+// recoveryAlphabet 恢复码字符集，去掉了容易混淆的字符
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// SetupTwoFactor 生成新的 TOTP 密钥，在使用动态验证码确认之前不会生效
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//
+// 返回值:
+//
+//	*types.TwoFactorSetup: 密钥、otpauth 地址及其二维码
+//	error: 已经开启两步验证或生成失败时返回的错误
+func (s *service) SetupTwoFactor(ctx context.Context, claims *types.GIClaims) (*types.TwoFactorSetup, error) {
+	var setup types.TwoFactorSetup
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		var totp model.UserTotp
+		err := s.GetDB(ctx).Model(&model.UserTotp{}).Where("userid = ?", claims.UserId).First(&totp).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Logger.Error().Err(err).Msg("查询两步验证设置失败")
+			return err
+		}
+		if totp.Enabled {
+			return exception.ErrAlreadyExist
+		}
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			return err
+		}
+		totp.UserId = claims.UserId
+		totp.Secret = secret
+		totp.LastStep = 0
+		if err := s.GetDB(ctx).Save(&totp).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("保存两步验证设置失败")
+			return err
+		}
+		setup.Secret = secret
+		setup.Uri = utils.TOTPProvisioningURI(defines.TOTP_ISSUER, user.Email, secret)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	b64s, err := utils.GenerateQRCodeBase64(setup.Uri)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("生成二维码失败")
+		return nil, err
+	}
+	setup.B64s = b64s
+	return &setup, nil
+}
+
+// EnableTwoFactor 使用第一个动态验证码确认密钥并开启两步验证，同时生成一组一次性恢复码
+// 参数:
+//
+//	ctx context.Context: 上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	confirm request.TwoFactorCode: 身份验证器上的动态验证码
+//
+// 返回值:
+//
+//	[]string: 恢复码明文，只在此时返回一次
+//	error: 未生成密钥或验证码错误时返回的错误
+func (s *service) EnableTwoFactor(ctx context.Context, claims *types.GIClaims, confirm request.TwoFactorCode) ([]string, error) {
+	var codes []string
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		var totp model.UserTotp
+		if err := s.GetDB(ctx).Model(&model.UserTotp{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("userid = ?", claims.UserId).
+			First(&totp).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		if totp.Enabled {
+			return exception.ErrAlreadyExist
+		}
+		step, ok := utils.VerifyTOTP(totp.Secret, confirm.Code, time.Now(), totp.LastStep)
+		if !ok {
+			return exception.ErrTwoFactorCode
+		}
+		if err := s.GetDB(ctx).Model(&totp).Updates(map[string]interface{}{"enabled": true, "last_step": step}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("开启两步验证失败")
+			return err
+		}
+		var err error
+		codes, err = s.resetRecoveryCodes(ctx, claims.UserId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 关闭两步验证，需要同时提供密码与动态验证码（或恢复码）
+func (s *service) DisableTwoFactor(ctx context.Context, claims *types.GIClaims, disable request.TwoFactorDisable) error {
+	return s.Transaction(ctx, func(ctx context.Context) error {
+		var user model.User
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", claims.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		if !utils.CompareHashPassword(user.Password, disable.Password) {
+			return exception.ErrPassword
+		}
+		if err := s.verifySecondFactor(ctx, claims.UserId, disable.Code); err != nil {
+			return err
+		}
+		if err := s.GetDB(ctx).Unscoped().Where("userid = ?", claims.UserId).Delete(&model.UserTotp{}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("关闭两步验证失败")
+			return err
+		}
+		if err := s.GetDB(ctx).Unscoped().Where("userid = ?", claims.UserId).Delete(&model.RecoveryCode{}).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("删除恢复码失败")
+			return err
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes 使用动态验证码确认后重新生成恢复码，旧的恢复码全部作废
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, claims *types.GIClaims, confirm request.TwoFactorCode) ([]string, error) {
+	var codes []string
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.verifySecondFactor(ctx, claims.UserId, confirm.Code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = s.resetRecoveryCodes(ctx, claims.UserId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTwoFactorLogin 使用登录挑战与动态验证码（或恢复码）完成登录
+// 同一登录挑战连续输错 LOGIN_CHALLENGE_TRIES 次后作废，需要重新使用密码登录
+// 参数:
+//
+//	ctx *gin.Context: Gin框架的上下文
+//	verify request.TwoFactorLogin: 登录挑战与验证码
+//
+// 返回值:
+//
+//	*types.TokenPair: 验证通过后签发的令牌
+//	error: 登录挑战无效或验证码错误时返回的错误
+func (s *service) VerifyTwoFactorLogin(ctx *gin.Context, verify request.TwoFactorLogin) (*types.TokenPair, error) {
+	key := defines.LOGIN_CHALLENGE + hashToken(verify.Challenge)
+	value := s.GetValue(ctx, key)
+	if value == "" {
+		return nil, exception.ErrInvalidToken
+	}
+	var challenge loginChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		return nil, exception.ErrInvalidToken
+	}
+	ip := ctx.ClientIP()
+	var user model.User
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", challenge.UserId).First(&user).Error; err != nil {
+			return exception.ErrNotFound
+		}
+		// 账号被锁定期间同样不能通过两步验证完成登录
+		if err := s.CheckLoginLock(ctx, ip, user.Email); err != nil {
+			return err
+		}
+		return s.verifySecondFactor(ctx, challenge.UserId, verify.Code)
+	})
+	if err != nil {
+		if errors.Is(err, exception.ErrTwoFactorCode) {
+			// 动态验证码错误与密码错误一样计入 IP 与账号的失败次数
+			s.RecordLoginFailure(ctx, ip, user.Email)
+			failKey := defines.LOGIN_CHALLENGE_FAIL + hashToken(verify.Challenge)
+			if count, err := s.IncrAndTime(ctx, failKey, 1, defines.LOGIN_CHALLENGE_TTL); err == nil && count >= defines.LOGIN_CHALLENGE_TRIES {
+				_ = s.DelValue(ctx, key, failKey)
+			}
+		}
+		if user.Uuid != "" {
+			s.recordLoginFailure(ctx, &user, challenge.Device, err)
+		}
+		return nil, err
+	}
+	// 登录挑战只能使用一次
+	_ = s.DelValue(ctx, key, defines.LOGIN_CHALLENGE_FAIL+hashToken(verify.Challenge))
+	return s.completeLogin(ctx, &user, challenge.Device, challenge.DeviceId)
+}
+
+// twoFactorEnabled 判断用户是否已开启两步验证
+func (s *service) twoFactorEnabled(ctx context.Context, userId string) bool {
+	var count int64
+	if err := s.GetDB(ctx).Model(&model.UserTotp{}).Where("userid = ? AND enabled = ?", userId, true).Count(&count).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("查询两步验证设置失败")
+	}
+	return count > 0
+}
+
+// createLoginChallenge 为密码验证通过的登录生成短期有效的登录挑战
+func (s *service) createLoginChallenge(ctx context.Context, userId, device, deviceId string) (string, error) {
+	challenge, err := newSecureToken()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(loginChallenge{UserId: userId, Device: device, DeviceId: deviceId})
+	if err != nil {
+		return "", err
+	}
+	if err := s.SetAndTime(ctx, defines.LOGIN_CHALLENGE+hashToken(challenge), string(data), defines.LOGIN_CHALLENGE_TTL); err != nil {
+		log.Logger.Error().Err(err).Msg("保存登录挑战失败")
+		return "", err
+	}
+	return challenge, nil
+}
+
+// verifySecondFactor 校验动态验证码或恢复码，需在事务中调用
+// 6位数字按动态验证码处理并记录时间步防止重放，其余按恢复码处理，恢复码使用后立即删除
+func (s *service) verifySecondFactor(ctx context.Context, userId, code string) error {
+	var totp model.UserTotp
+	if err := s.GetDB(ctx).Model(&model.UserTotp{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("userid = ? AND enabled = ?", userId, true).
+		First(&totp).Error; err != nil {
+		return exception.ErrNotFound
+	}
+	code = strings.TrimSpace(code)
+	if step, ok := utils.VerifyTOTP(totp.Secret, code, time.Now(), totp.LastStep); ok {
+		if err := s.GetDB(ctx).Model(&totp).Update("last_step", step).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新两步验证时间步失败")
+			return err
+		}
+		return nil
+	}
+	result := s.GetDB(ctx).Unscoped().
+		Where("userid = ? AND code_hash = ?", userId, hashToken(normalizeRecoveryCode(code))).
+		Limit(1).
+		Delete(&model.RecoveryCode{})
+	if result.Error != nil {
+		log.Logger.Error().Err(result.Error).Msg("校验恢复码失败")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return exception.ErrTwoFactorCode
+	}
+	return nil
+}
+
+// resetRecoveryCodes 删除旧的恢复码并生成新的一组，数据库中只保存哈希
+func (s *service) resetRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	if err := s.GetDB(ctx).Unscoped().Where("userid = ?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("删除恢复码失败")
+		return nil, err
+	}
+	codes := make([]string, 0, defines.RECOVERY_CODE_COUNT)
+	records := make([]model.RecoveryCode, 0, defines.RECOVERY_CODE_COUNT)
+	for i := 0; i < defines.RECOVERY_CODE_COUNT; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, model.RecoveryCode{UserId: userId, CodeHash: hashToken(normalizeRecoveryCode(code))})
+	}
+	if err := s.GetDB(ctx).Create(&records).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("保存恢复码失败")
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode 生成形如 xxxxx-xxxxx 的恢复码
+func newRecoveryCode() (string, error) {
+	code := make([]byte, 0, 11)
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code = append(code, recoveryAlphabet[n.Int64()])
+	}
+	return string(code), nil
+}
+
+// normalizeRecoveryCode 忽略恢复码中的分隔符与大小写
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -36,37 +36,12 @@ type UserFriendService interface {
 func (s *service) AddFriend(ctx *gin.Context, claims *types.GIClaims, request request.FriendRequest) error {
 	// 使用事务处理来确保数据一致性
 	err := s.Transaction(ctx, func(ctx context.Context) error {
-		// 查询好友信息，根据提供的用户名或邮箱定位用户
-		var friend model.User
-		if err := s.GetDB(ctx).Model(&friend).Where("username = ?", request.FriendInfo).Or("email = ?", request.FriendInfo).First(&friend).Error; err != nil {
-			// 如果找不到指定的好友，则返回未找到错误
-			return exception.ErrNotFound
-		}
-		// 检查当前用户与目标用户是否已经是好友
-		if err := checkIsFriend(s.GetDB(ctx), claims.UserId, friend.Uuid); err != nil {
-			// 如果已经是好友或者出现其他错误，则返回相应的错误
-			return err
-		}
-		// 创建用户与好友的关系记录
-		var userFriend model.UserFriend
-		userFriend.UserId = claims.UserId
-		userFriend.FriendId = friend.Uuid
-		if err := s.GetDB(ctx).Create(&userFriend).Error; err != nil {
-			// 如果插入失败，则记录错误日志并返回错误
-			log.Logger.Error().Err(err).Msg("插入失败")
+		// 查询好友信息，根据提供的用户名或邮箱定位用户，对方关闭了对应的查找方式时视为不存在
+		friend, err := s.findSearchableUser(ctx, request.FriendInfo)
+		if err != nil {
 			return err
 		}
-		// 创建好友与用户的关系记录，确保双向关系
-		var friendUser model.UserFriend
-		friendUser.UserId = friend.Uuid
-		friendUser.FriendId = claims.UserId
-		if err := s.GetDB(ctx).Create(&friendUser).Error; err != nil {
-			// 如果插入失败，则记录错误日志并返回错误
-			log.Logger.Error().Err(err).Msg("插入失败")
-			return err
-		}
-		// 事务执行成功，返回nil
-		return nil
+		return s.requestFriend(ctx, claims.UserId, friend, request.Answer)
 	})
 	// 如果事务处理过程中出现错误，则返回错误
 	if err != nil {
@@ -76,6 +51,54 @@ func (s *service) AddFriend(ctx *gin.Context, claims *types.GIClaims, request re
 	return nil
 }
 
+// requestFriend 向目标用户发起好友申请，需在事务中调用
+// 根据对方的好友申请策略拒绝、校验答案或自动通过，自动通过时同时记录双方的通讯录变更
+func (s *service) requestFriend(ctx context.Context, userId string, friend *model.User, answer string) error {
+	// 检查当前用户与目标用户是否已经是好友
+	if err := checkIsFriend(s.GetDB(ctx), userId, friend.Uuid); err != nil {
+		// 如果已经是好友或者出现其他错误，则返回相应的错误
+		return err
+	}
+	// 根据对方的好友申请策略决定拒绝、校验答案或自动通过
+	privacy, err := s.getPrivacy(ctx, friend.Uuid)
+	if err != nil {
+		return err
+	}
+	autoAccept, err := checkFriendPolicy(privacy, answer)
+	if err != nil {
+		return err
+	}
+	// 创建用户与好友的关系记录
+	var userFriend model.UserFriend
+	userFriend.UserId = userId
+	userFriend.FriendId = friend.Uuid
+	if err := s.GetDB(ctx).Create(&userFriend).Error; err != nil {
+		// 如果插入失败，则记录错误日志并返回错误
+		log.Logger.Error().Err(err).Msg("插入失败")
+		return err
+	}
+	// 创建好友与用户的关系记录，确保双向关系
+	var friendUser model.UserFriend
+	friendUser.UserId = friend.Uuid
+	friendUser.FriendId = userId
+	if err := s.GetDB(ctx).Create(&friendUser).Error; err != nil {
+		// 如果插入失败，则记录错误日志并返回错误
+		log.Logger.Error().Err(err).Msg("插入失败")
+		return err
+	}
+	// 对方设置了自动通过时直接建立好友关系
+	if autoAccept {
+		if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+			Where("id IN ?", []uint{userFriend.ID, friendUser.ID}).
+			Update("status", enums.IS_FRIEND).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新失败")
+			return err
+		}
+		return s.recordFriendshipChange(ctx, enums.CONTACT_ADD, userId, friend.Uuid)
+	}
+	return nil
+}
+
 // checkIsFriend 检查两个用户是否已经是朋友关系
 // 参数:
 //
@@ -117,12 +140,13 @@ func (s *service) GetFriendList(ctx *gin.Context, claims *types.GIClaims) ([]typ
 	// 使用事务处理，确保数据查询的一致性和完整性
 	err := s.Transaction(ctx, func(ctx context.Context) error {
 		// 通过用户ID查询好友信息，排除不是好友的状态
-		if err := s.GetDB(ctx).Model(&model.UserFriend{}).Select("user.email, user.username, user.avatar,user_friend.status").
+		if err := s.GetDB(ctx).Model(&model.UserFriend{}).Select("user.uuid, user.username, user.nickname, user.avatar, user.last_seen,user_friend.status").
 			Joins("JOIN user ON user_friend.friendid = user.uuid").
 			Where("user_friend.userid = ? AND user_friend.status != ?", claims.UserId, enums.NOT_FRIEND).Scan(&friendList).Error; err != nil {
 			log.Logger.Error().Err(err).Msg("查询失败")
 			return exception.ErrNotFound
 		}
+		s.hideLastSeen(ctx, claims.UserId, friendProfiles(friendList)...)
 		return nil
 	})
 	if err != nil {
@@ -158,11 +182,12 @@ func (s *service) AddToBlackList(ctx *gin.Context, claims *types.GIClaims, frien
 		}
 
 		// 删除与指定用户的好友关系（软删除），如果存在好友关系
-		if err := s.GetDB(ctx).Model(&model.UserFriend{}).
+		result := s.GetDB(ctx).Model(&model.UserFriend{}).
 			Where("userid = ? AND friendid = ?", claims.UserId, friend.Uuid).
 			Or("userid = ? AND friendid = ?", friend.Uuid, claims.UserId).
 			Where("status = ?", enums.IS_FRIEND).
-			Delete(&model.UserFriend{}).Error; err != nil {
+			Delete(&model.UserFriend{})
+		if err := result.Error; err != nil {
 			log.Logger.Error().Err(err).Msg("删除失败")
 			return err
 		}
@@ -181,6 +206,10 @@ func (s *service) AddToBlackList(ctx *gin.Context, claims *types.GIClaims, frien
 			log.Logger.Error().Err(err).Msg("更新失败")
 			return err
 		}
+		// 拉黑后双方都会从对方的好友列表中移除
+		if result.RowsAffected > 0 {
+			return s.recordFriendshipChange(ctx, enums.CONTACT_REMOVE, claims.UserId, friend.Uuid)
+		}
 		return nil
 	})
 }
@@ -202,7 +231,7 @@ func (s *service) GetBlackList(ctx *gin.Context, claims *types.GIClaims) ([]type
 	err := s.Transaction(ctx, func(ctx context.Context) error {
 		// 查询已删除的用户好友关系，获取黑名单列表
 		if err := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).
-			Select("user.email, user.username, user.avatar,user_friend.status").
+			Select("user.uuid, user.username, user.nickname, user.avatar, user.last_seen,user_friend.status").
 			Joins("JOIN user ON user_friend.friendid = user.uuid").
 			Where("user_friend.userid = ?", claims.UserId).
 			Where("user_friend.deleted_at IS NOT NULL").
@@ -238,14 +267,19 @@ func (s *service) CancelBlack(ctx *gin.Context, claims *types.GIClaims, friendIn
 			return exception.ErrNotFound
 		}
 		// 更新UserFriend表，恢复与朋友的关联，并设置为正常好友状态。
-		if err := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).
+		result := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).
 			Where("userid = ? AND friendid = ?", claims.UserId, friend.Uuid).
 			Or("userid = ? AND friendid = ?", friend.Uuid, claims.UserId).
 			Where("deleted_at IS NOT NULL").
-			Updates(map[string]interface{}{"deleted_at": nil, "status": enums.IS_FRIEND}).Error; err != nil {
+			Updates(map[string]interface{}{"deleted_at": nil, "status": enums.IS_FRIEND})
+		if err := result.Error; err != nil {
 			log.Logger.Error().Err(err).Msg("更新失败")
 			return err
 		}
+		// 恢复好友关系后双方通讯录都会重新出现对方
+		if result.RowsAffected > 0 {
+			return s.recordFriendshipChange(ctx, enums.CONTACT_ADD, claims.UserId, friend.Uuid)
+		}
 		return nil
 	})
 }
@@ -270,10 +304,15 @@ func (s *service) DeleteFriend(ctx *gin.Context, claims *types.GIClaims, friendI
 			return exception.ErrNotFound
 		}
 		// 删除用户和好友之间的关系，如果删除失败，返回错误
-		if err := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).Where("userid = ? AND friendid = ?", claims.UserId, friend.Uuid).Or("userid = ? AND friendid = ?", friend.Uuid, claims.UserId).Delete(&model.UserFriend{}).Error; err != nil {
+		result := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).Where("userid = ? AND friendid = ?", claims.UserId, friend.Uuid).Or("userid = ? AND friendid = ?", friend.Uuid, claims.UserId).Delete(&model.UserFriend{})
+		if err := result.Error; err != nil {
 			log.Logger.Error().Err(err).Msg("删除失败")
 			return err
 		}
+		// 记录双方通讯录的删除变更
+		if result.RowsAffected > 0 {
+			return s.recordFriendshipChange(ctx, enums.CONTACT_REMOVE, claims.UserId, friend.Uuid)
+		}
 		return nil
 	})
 }
@@ -303,13 +342,29 @@ func (s *service) AgreeFriendRequest(ctx *gin.Context, claims *types.GIClaims, f
 			return exception.ErrNotFound
 		}
 		// 更新用户关系状态为好友，无论谁先添加对方，都更新双方的关系状态
-		if err := s.GetDB(ctx).Model(&model.UserFriend{}).
-			Where("userid = ? AND friendid =?", claims.UserId, friend.Uuid).
-			Or("userid = ? AND friendid =?", friend.Uuid, claims.UserId).
-			Update("status", enums.IS_FRIEND).Error; err != nil {
+		// 乐观锁会让每次更新都修改版本号，只更新尚未成为好友的记录，重复同意时不会再记录变更
+		result := s.GetDB(ctx).Model(&model.UserFriend{}).
+			Where(s.GetDB(ctx).Where("userid = ? AND friendid =?", claims.UserId, friend.Uuid).
+				Or("userid = ? AND friendid =?", friend.Uuid, claims.UserId)).
+			Where("status <> ?", enums.IS_FRIEND).
+			Update("status", enums.IS_FRIEND)
+		if err := result.Error; err != nil {
 			log.Logger.Error().Err(err).Msg("更新失败")
 			return err
 		}
+		// 记录双方通讯录的新增变更
+		if result.RowsAffected > 0 {
+			return s.recordFriendshipChange(ctx, enums.CONTACT_ADD, claims.UserId, friend.Uuid)
+		}
 		return nil
 	})
 }
+
+// friendProfiles 取出好友列表中每个元素的公开资料指针，便于统一处理隐私字段
+func friendProfiles(friends []types.Friend) []*types.PublicProfile {
+	profiles := make([]*types.PublicProfile, 0, len(friends))
+	for i := range friends {
+		profiles = append(profiles, &friends[i].PublicProfile)
+	}
+	return profiles
+}
@@ -6,49 +6,72 @@ import (
 	"Gin-IM/pkg/enums"
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/request"
-	"Gin-IM/pkg/token"
 	"Gin-IM/pkg/types"
 	"Gin-IM/pkg/utils"
 	"context"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
 type UserService interface {
 	Register(ctx *gin.Context, register request.Register) error
 
-	Login(ctx *gin.Context, login request.Login) (string, error)
+	Login(ctx *gin.Context, login request.Login) (*types.LoginResult, error)
 
 	GetUserInfo(ctx *gin.Context, claims *types.GIClaims) (*model.User, error)
 
 	Logout(ctx *gin.Context, claims *types.GIClaims) error
 
-	Search(ctx *gin.Context, search request.UserSearch) (*model.User, error)
+	Search(ctx *gin.Context, claims *types.GIClaims, search request.UserSearch) (*types.Page[types.PublicProfile], error)
 }
 
+// Register 注册新用户，账号在邮箱验证通过之前无法登录
 func (s *service) Register(ctx *gin.Context, register request.Register) error {
-	return s.Transaction(ctx, func(ctx context.Context) error {
-		var user model.User
-		if err := s.GetDB(ctx).Model(&user).Where("email = ? or username = ?", register.Email, register.UserName).First(&user).Error; err == nil {
+	var user model.User
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		// 宽限期内的已注销账号仍然占用邮箱与用户名
+		if err := s.GetDB(ctx).Unscoped().Model(&user).Where("email = ? or username = ?", register.Email, register.UserName).First(&user).Error; err == nil {
 			return exception.ErrAlreadyExist
 		}
 		user.Uuid = uuid.New().String()
 		user.Email = register.Email
+		user.EmailHash = utils.HashEmail(register.Email)
 		user.Username = register.UserName
-		user.Password = utils.GernerateHashPassword(register.Password)
+		password, err := utils.GernerateHashPassword(register.Password)
+		if err != nil {
+			log.Logger.Error().Err(err).Msg("计算密码哈希失败")
+			return err
+		}
+		user.Password = password
 		if err := s.GetDB(ctx).Create(&user).Error; err != nil {
 			log.Logger.Error().Err(err).Msg("创建用户失败")
 			return err
 		}
+		// email_verified 的默认值为 true 以兼容已有用户，新用户需要单独置为未验证
+		if err := s.GetDB(ctx).Model(&model.User{}).Where("id = ?", user.ID).UpdateColumn("email_verified", false).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("创建用户失败")
+			return err
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	// 验证邮件发送失败时用户可以重新发送，不影响注册结果
+	if err := s.sendVerifyCode(ctx, &user); err != nil {
+		log.Logger.Error().Err(err).Msg("发送验证邮件失败")
+	}
+	return nil
 }
 
 // Login 用户登录函数
-// 该函数接收一个登录请求，包含用户邮箱和密码，验证用户信息并返回JWT令牌
+// 该函数接收一个登录请求，包含用户邮箱和密码，验证用户信息后为当前设备创建会话并返回令牌
+// 每个设备拥有独立的会话，在新设备登录不会影响其他设备；开启了两步验证的用户只会得到登录挑战
 // 参数:
 //
 //	ctx *gin.Context: Gin框架的上下文，用于处理HTTP请求和响应
@@ -56,51 +79,86 @@ func (s *service) Register(ctx *gin.Context, register request.Register) error {
 //
 // 返回值:
 //
-//	string: 成功登录后返回的JWT令牌
+//	*types.LoginResult: 成功登录后返回的访问令牌与刷新令牌，或需要两步验证时的登录挑战
 //	error: 登录过程中可能遇到的错误，如果用户不存在、密码错误或数据库操作失败等
-func (s *service) Login(ctx *gin.Context, login request.Login) (string, error) {
+func (s *service) Login(ctx *gin.Context, login request.Login) (*types.LoginResult, error) {
+	ip := ctx.ClientIP()
 	var user model.User
 	// 使用事务处理登录过程中的数据库操作
 	err := s.Transaction(ctx, func(ctx context.Context) error {
-		// 查询用户邮箱是否存在于数据库中
-		if err := s.GetDB(ctx).Model(&user).Where("email = ?", login.Email).First(&user).Error; err != nil {
-			return exception.ErrNotFound
+		// 查询用户邮箱是否存在于数据库中，邮箱不存在与密码错误返回相同的错误
+		found := s.GetDB(ctx).Model(&user).Where("email = ?", login.Email).First(&user).Error == nil
+		if !found {
+			// 宽限期内的已注销账号在密码正确时提示可以恢复
+			if deleted, ok := s.findDeletedAccount(ctx, login.Email, login.Password); ok {
+				return deletionError(deleted)
+			}
 		}
-		// 验证用户密码是否正确
-		if !utils.CompareHashPassword(user.Password, login.Password) {
-			return exception.ErrPassword
+		// 目录账号与启用 LDAP 后首次登录的用户通过 LDAP 认证，其余用户验证本地密码
+		var authenticated bool
+		if s.ldap != nil && (!found || s.isDirectoryUser(ctx, user.Uuid)) {
+			authenticated = s.ldapLogin(ctx, login.Email, login.Password, &user) == nil
+		} else if found {
+			authenticated = utils.CompareHashPassword(user.Password, login.Password)
+			if authenticated && utils.NeedsRehash(user.Password) {
+				s.rehashPassword(ctx, &user, login.Password)
+			}
 		}
-		// 判断用户是否已经登录
-		if len(s.GetValue(ctx, defines.USER_TOKEN_KEY+user.Uuid)) != 0 {
-			return exception.ErrAlreadyLogin
+		// 机器人只能使用 API 密钥访问
+		if !authenticated || user.BotOwner != "" {
+			s.RecordLoginFailure(ctx, ip, login.Email)
+			return exception.ErrLoginFailed
 		}
-		// 更新用户状态为登录状态
-		if err := s.GetDB(ctx).Model(&user).Where("uuid = ?", user.Uuid).Update("status", enums.LogIn).Error; err != nil {
-			log.Logger.Error().Err(err).Msg("更新用户状态失败")
-			return err
-		}
-		// 设置用户token到缓存中
-		if err := s.SetAndTime(ctx, defines.USER_TOKEN_KEY+user.Uuid, user.Uuid+user.Email, defines.USER_TOKEN); err != nil {
-			log.Logger.Error().Err(err).Msg("设置用户token失败")
-			return err
+		// 邮箱验证通过之前不允许登录
+		if !user.EmailVerified {
+			return exception.ErrEmailNotVerified
 		}
-		return nil
+		return s.checkBan(ctx, &user)
 	})
 	if err != nil {
-		return "", err
+		if user.Email == "" {
+			user.Email = login.Email
+		}
+		s.recordLoginFailure(ctx, &user, login.Device, err)
+		return nil, err
+	}
+	return s.loginResult(ctx, &user, login.Device, login.DeviceId)
+}
+
+// loginResult 第一步身份验证通过后，开启了两步验证的用户返回登录挑战，其余用户直接签发令牌
+func (s *service) loginResult(ctx *gin.Context, user *model.User, device, deviceId string) (*types.LoginResult, error) {
+	// 开启了两步验证时，验证动态验证码之后才签发令牌
+	if s.twoFactorEnabled(ctx, user.Uuid) {
+		challenge, err := s.createLoginChallenge(ctx, user.Uuid, device, deviceId)
+		if err != nil {
+			return nil, err
+		}
+		return &types.LoginResult{TwoFactorRequired: true, Challenge: challenge}, nil
+	}
+	tokens, err := s.completeLogin(ctx, user, device, deviceId)
+	if err != nil {
+		return nil, err
+	}
+	return &types.LoginResult{Tokens: tokens}, nil
+}
+
+// completeLogin 身份验证全部通过后更新用户状态，为当前设备创建会话并记录登录历史
+// 失败计数在这里才清除，设备也在这里才记为可信，只通过了密码验证、未完成两步验证的登录不算成功
+func (s *service) completeLogin(ctx *gin.Context, user *model.User, device, deviceId string) (*types.TokenPair, error) {
+	// 更新用户状态为登录状态
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("uuid = ?", user.Uuid).Updates(map[string]interface{}{"status": enums.LogIn, "last_seen": time.Now()}).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("更新用户状态失败")
+		return nil, err
+	}
+	// 为当前设备创建会话并生成令牌
+	tokens, err := s.createSession(ctx, user, device)
+	if err != nil {
+		return nil, err
 	}
-	// 生成JWT令牌
-	claims := types.GIClaims{
-		UserId: user.Uuid,
-		Admin:  utils.IsAdmin(user.Email),
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: &jwt.NumericDate{
-				Time: time.Now().Add(time.Hour * defines.TOKEN_EXPIRE),
-			},
-		},
-	}
-	tokenString := token.GernerateToken(claims)
-	return tokenString, nil
+	s.clearLoginFailure(ctx, user.Email)
+	s.trustDevice(ctx, ctx.ClientIP(), user.Email, deviceId)
+	s.recordLogin(ctx, user, device, tokens.SessionId, "")
+	return tokens, nil
 }
 
 func (s *service) GetUserInfo(ctx *gin.Context, claims *types.GIClaims) (*model.User, error) {
@@ -120,12 +178,19 @@ func (s *service) GetUserInfo(ctx *gin.Context, claims *types.GIClaims) (*model.
 func (s *service) Logout(ctx *gin.Context, claims *types.GIClaims) error {
 	var user model.User
 	err := s.Transaction(ctx, func(ctx context.Context) error {
-		if err := s.GetDB(ctx).Model(&user).Where("uuid = ?", claims.UserId).Update("status", enums.LogOut).Error; err != nil {
-			log.Logger.Error().Err(err).Msg("更新用户状态失败")
+		// 只注销当前设备的会话
+		if err := s.deleteSession(ctx, claims.UserId, claims.SessionId); err != nil {
+			log.Logger.Error().Err(err).Msg("删除用户token失败")
 			return err
 		}
-		if err := s.DelValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); err != nil {
-			log.Logger.Error().Err(err).Msg("删除用户token失败")
+		updates := map[string]interface{}{"last_seen": time.Now()}
+		// 所有设备都已退出时才更新为退出状态
+		if len(s.GetSetMembers(ctx, defines.USER_SESSIONS+claims.UserId)) == 0 {
+			updates["status"] = enums.LogOut
+		}
+		// 已封禁的用户不会被改回退出状态
+		if err := s.GetDB(ctx).Model(&user).Where("uuid = ? AND status <> ?", claims.UserId, enums.Forbid).Updates(updates).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("更新用户状态失败")
 			return err
 		}
 		return nil
@@ -136,17 +201,109 @@ func (s *service) Logout(ctx *gin.Context, claims *types.GIClaims) error {
 	return nil
 }
 
-func (s *service) Search(ctx *gin.Context, search request.UserSearch) (*model.User, error) {
-	var user model.User
+// Search 搜索用户
+// 输入包含 @ 时按邮箱精确匹配，否则按用户名或昵称模糊匹配，前缀匹配的结果排在前面。
+// 搜索结果只包含公开资料，并排除自己、已封禁的用户以及拉黑了当前用户的用户。
+// 参数:
+//
+//	ctx *gin.Context: Gin框架的上下文
+//	claims *types.GIClaims: 当前用户的令牌声明
+//	search request.UserSearch: 搜索关键字与分页信息
+//
+// 返回值:
+//
+//	*types.Page[types.PublicProfile]: 分页后的公开资料列表
+//	error: 查询失败时返回的错误
+func (s *service) Search(ctx *gin.Context, claims *types.GIClaims, search request.UserSearch) (*types.Page[types.PublicProfile], error) {
+	page := &types.Page[types.PublicProfile]{
+		Page:     search.Page,
+		PageSize: search.PageSize,
+		List:     make([]types.PublicProfile, 0),
+	}
+	if page.Page == 0 {
+		page.Page = 1
+	}
+	if page.PageSize == 0 {
+		page.PageSize = defines.SEARCH_PAGE_SIZE
+	}
+	keyword := strings.TrimSpace(search.UserInfo)
 	err := s.Transaction(ctx, func(ctx context.Context) error {
-		if err := s.GetDB(ctx).Model(&user).Where("username = ?", search.UserInfo).Or("email = ?", search.UserInfo).First(&user).Error; err != nil {
+		// 拉黑了当前用户的用户不会出现在搜索结果中
+		blockedBy := s.GetDB(ctx).Unscoped().Model(&model.UserFriend{}).
+			Select("userid").
+			Where("friendid = ? AND status = ?", claims.UserId, enums.BLACK)
+		// 未保存隐私设置的用户使用默认设置，即允许被搜索
+		db := s.GetDB(ctx).Model(&model.User{}).
+			Joins("LEFT JOIN user_privacy ON user_privacy.userid = user.uuid AND user_privacy.deleted_at IS NULL").
+			Where("user.uuid <> ?", claims.UserId).
+			Where("user.status <> ?", enums.Forbid).
+			Where("user.uuid NOT IN (?)", blockedBy).
+			Session(&gorm.Session{})
+		order := clause.OrderBy{Columns: []clause.OrderByColumn{{Column: clause.Column{Table: "user", Name: "username"}}}}
+		if strings.Contains(keyword, "@") {
+			db = db.Where("user.email = ?", keyword).
+				Where("user_privacy.id IS NULL OR user_privacy.search_by_email = ?", true)
+		} else {
+			pattern := escapeLike(keyword)
+			db = db.Where("user.username LIKE ? OR user.nickname LIKE ?", "%"+pattern+"%", "%"+pattern+"%").
+				Where("user_privacy.id IS NULL OR user_privacy.search_by_username = ?", true)
+			// 前缀匹配优先于包含匹配
+			order = clause.OrderBy{Expression: clause.Expr{
+				SQL:                "CASE WHEN user.username LIKE ? OR user.nickname LIKE ? THEN 0 ELSE 1 END, user.username",
+				Vars:               []interface{}{pattern + "%", pattern + "%"},
+				WithoutParentheses: true,
+			}}
+		}
+		if err := db.Count(&page.Total).Error; err != nil {
 			log.Logger.Error().Err(err).Msg("查询用户失败")
 			return exception.ErrNotFound
 		}
+		if err := db.Select("user.uuid, user.username, user.nickname, user.avatar, user.last_seen").
+			Order(order).
+			Offset((page.Page - 1) * page.PageSize).
+			Limit(page.PageSize).
+			Scan(&page.List).Error; err != nil {
+			log.Logger.Error().Err(err).Msg("查询用户失败")
+			return exception.ErrNotFound
+		}
+		profiles := make([]*types.PublicProfile, 0, len(page.List))
+		for i := range page.List {
+			profiles = append(profiles, &page.List[i])
+		}
+		s.hideLastSeen(ctx, claims.UserId, profiles...)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return page, nil
+}
+
+// escapeLike 转义 LIKE 查询中的通配符
+func escapeLike(keyword string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(keyword)
+}
+
+// rehashPassword 使用当前的算法与参数重新计算密码哈希，失败时只记录日志，不影响登录
+func (s *service) rehashPassword(ctx context.Context, user *model.User, password string) {
+	hash, err := utils.GernerateHashPassword(password)
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("重新计算密码哈希失败")
+		return
+	}
+	// 只更新密码列，不触发乐观锁版本与更新时间的变化
+	if err := s.GetDB(ctx).Model(&model.User{}).Where("id = ?", user.ID).UpdateColumn("password", hash).Error; err != nil {
+		log.Logger.Error().Err(err).Msg("更新密码哈希失败")
+		return
+	}
+	user.Password = hash
+}
+
+// randomPasswordHash 为没有本地密码的账号（第三方登录、目录账号与机器人）生成随机密码的哈希，该密码不会告知任何人
+func randomPasswordHash() (string, error) {
+	secret, err := newSecureToken()
+	if err != nil {
+		return "", err
+	}
+	return utils.GernerateHashPassword(secret)
 }
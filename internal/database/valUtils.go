@@ -3,15 +3,26 @@ package database
 import (
 	"context"
 	"github.com/rs/zerolog/log"
+	"github.com/valkey-io/valkey-go"
+	"strconv"
 )
 
 type ValkeyService interface {
 	SetAndTime(ctx context.Context, key, value string, timeout int64) error
+	SetValue(ctx context.Context, key, value string) error
+	SetNxAndTime(ctx context.Context, key, value string, timeout int64) (bool, error)
+	SetXxAndTime(ctx context.Context, key, value string, timeout int64) (bool, error)
 	GetValue(ctx context.Context, key string) string
 	DelValue(ctx context.Context, keys ...string) error
+	DelIfValue(ctx context.Context, key, value string) (bool, error)
 	SetListAndTime(ctx context.Context, key string, list []string, timeout int64) error
 	//AddToList(ctx context.Context, key string, element string) error
 	GetList(ctx context.Context, key string) []string
+	IncrAndTime(ctx context.Context, key string, increment, timeout int64) (int64, error)
+	AddToSetAndTime(ctx context.Context, key, member string, timeout int64) error
+	RemoveFromSet(ctx context.Context, key string, members ...string) error
+	GetSetMembers(ctx context.Context, key string) []string
+	Publish(ctx context.Context, channel, message string) error
 }
 
 // SetAndTime 是一个方法，用于在服务中设置一个具有过期时间的键值对。
@@ -31,6 +42,37 @@ func (s *service) SetAndTime(ctx context.Context, key, value string, timeout int
 	return s.valClient.Do(ctx, s.valClient.B().Setex().Key(key).Seconds(timeout).Value(value).Build()).Error()
 }
 
+// SetValue 设置一个不会过期的键值对，已存在的过期时间会被清除
+func (s *service) SetValue(ctx context.Context, key, value string) error {
+	return s.valClient.Do(ctx, s.valClient.B().Set().Key(key).Value(value).Build()).Error()
+}
+
+// SetNxAndTime 只在键不存在时设置带过期时间的键值对，返回是否设置成功，用于原子地占用一次性凭证
+func (s *service) SetNxAndTime(ctx context.Context, key, value string, timeout int64) (bool, error) {
+	err := s.valClient.Do(ctx, s.valClient.B().Set().Key(key).Value(value).Nx().ExSeconds(timeout).Build()).Error()
+	if valkey.IsValkeyNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("valkey set nx error")
+		return false, err
+	}
+	return true, nil
+}
+
+// SetXxAndTime 只在键已存在时更新值并刷新过期时间，返回是否更新成功，用于避免改写已被删除的数据
+func (s *service) SetXxAndTime(ctx context.Context, key, value string, timeout int64) (bool, error) {
+	err := s.valClient.Do(ctx, s.valClient.B().Set().Key(key).Value(value).Xx().ExSeconds(timeout).Build()).Error()
+	if valkey.IsValkeyNil(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("valkey set xx error")
+		return false, err
+	}
+	return true, nil
+}
+
 // GetValue 通过键值获取对应的值。
 // 该方法使用 valClient 执行获取值的操作，主要执行以下步骤：
 // 1. 使用传入的上下文和键值构建并发送一个获取值的请求。
@@ -138,3 +180,125 @@ func (s *service) GetList(ctx context.Context, key string) []string {
 		return val
 	}
 }
+
+// delIfScript 值与参数相同时才删除键，读取与删除在同一条脚本中完成
+var delIfScript = valkey.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// DelIfValue 键的值等于 value 时删除该键，返回是否删除，用于原子地消费一次性凭证
+func (s *service) DelIfValue(ctx context.Context, key, value string) (bool, error) {
+	deleted, err := delIfScript.Exec(ctx, s.valClient, []string{key}, []string{value}).AsInt64()
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("valkey del error")
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+// incrScript 在一条脚本中增加计数并为没有过期时间的计数器设置过期时间，
+// 避免 INCRBY 成功而 EXPIRE 失败时计数器永不过期
+var incrScript = valkey.NewLuaScript(`
+local count = redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('TTL', KEYS[1]) < 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[2])
+end
+return count
+`)
+
+// IncrAndTime 将键的值增加 increment，并在键首次创建时设置过期时间，常用于固定窗口计数。
+// 参数:
+//
+//	ctx - 上下文，用于取消请求和传递请求级值。
+//	key - 计数器的键。
+//	increment - 本次增加的数量。
+//	timeout - 计数窗口的长度，单位为秒。
+//
+// 返回值:
+//
+//	int64 - 增加后的计数值。
+//	error - 执行命令失败时返回的错误。
+func (s *service) IncrAndTime(ctx context.Context, key string, increment, timeout int64) (int64, error) {
+	count, err := incrScript.Exec(ctx, s.valClient, []string{key}, []string{strconv.FormatInt(increment, 10), strconv.FormatInt(timeout, 10)}).AsInt64()
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("valkey incr error")
+		return 0, err
+	}
+	return count, nil
+}
+
+// AddToSetAndTime 向集合中添加成员，并刷新集合的过期时间。
+// 参数:
+//
+//	ctx - 上下文，用于取消请求和传递请求级值。
+//	key - 集合的键。
+//	member - 要添加的成员。
+//	timeout - 集合的过期时间，单位为秒。
+//
+// 返回值:
+//
+//	error - 执行命令失败时返回的错误。
+func (s *service) AddToSetAndTime(ctx context.Context, key, member string, timeout int64) error {
+	if err := s.valClient.Do(ctx, s.valClient.B().Sadd().Key(key).Member(member).Build()).Error(); err != nil {
+		log.Logger.Error().Err(err).Msg("valkey sadd error")
+		return err
+	}
+	return s.valClient.Do(ctx, s.valClient.B().Expire().Key(key).Seconds(timeout).Build()).Error()
+}
+
+// RemoveFromSet 从集合中移除成员。
+// 参数:
+//
+//	ctx - 上下文，用于取消请求和传递请求级值。
+//	key - 集合的键。
+//	members - 要移除的成员。
+//
+// 返回值:
+//
+//	error - 执行命令失败时返回的错误。
+func (s *service) RemoveFromSet(ctx context.Context, key string, members ...string) error {
+	if err := s.valClient.Do(ctx, s.valClient.B().Srem().Key(key).Member(members...).Build()).Error(); err != nil {
+		log.Logger.Error().Err(err).Msg("valkey srem error")
+		return err
+	}
+	return nil
+}
+
+// GetSetMembers 获取集合中的全部成员。
+// 参数:
+//
+//	ctx - 上下文，用于取消请求和传递请求级值。
+//	key - 集合的键。
+//
+// 返回值:
+//
+//	字符串切片，集合不存在或获取失败时返回nil。
+func (s *service) GetSetMembers(ctx context.Context, key string) []string {
+	val, err := s.valClient.Do(ctx, s.valClient.B().Smembers().Key(key).Build()).AsStrSlice()
+	if err != nil {
+		log.Logger.Error().Err(err).Msg("valkey smembers error")
+		return nil
+	}
+	return val
+}
+
+// Publish 向频道发布一条消息，订阅该频道的实例会收到通知。
+// 参数:
+//
+//	ctx - 上下文，用于取消请求和传递请求级值。
+//	channel - 频道名称。
+//	message - 消息内容。
+//
+// 返回值:
+//
+//	error - 执行命令失败时返回的错误。
+func (s *service) Publish(ctx context.Context, channel, message string) error {
+	if err := s.valClient.Do(ctx, s.valClient.B().Publish().Channel(channel).Message(message).Build()).Error(); err != nil {
+		log.Logger.Error().Err(err).Msg("valkey publish error")
+		return err
+	}
+	return nil
+}
@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/enums"
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/request"
 	"Gin-IM/pkg/response"
@@ -14,11 +14,11 @@ import (
 
 // GetCaptcha 获取验证码
 // @Summary 获取验证码
-// @Description 获取验证码
+// @Description 获取验证码，类型由服务端配置决定：digit、string、math 为图片，audio 为音频，pow 为工作量证明挑战
 // @Tags 账户管理
 // @Accept  json
 // @Produce  json
-// @Success 200 {object} response.Response "返回结果"
+// @Success 200 {object} response.Response{data=types.CaptDateBase64} "返回结果"
 // @Failure 200 {object} response.Response "返回结果"
 // @Router /api/account/getcaptcha [get]
 func (h *Handlers) GetCaptcha(ctx *gin.Context) {
@@ -59,17 +59,133 @@ func (h *Handlers) Register(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	ctx.JSON(http.StatusOK, response.Success(0, "注册成功", nil))
+	ctx.JSON(http.StatusOK, response.Success(0, "注册成功，请查收验证邮件", nil))
+}
+
+// VerifyEmail 验证邮箱
+// @Summary 验证邮箱
+// @Description 使用注册后邮件中的验证码激活账号
+// @Tags 账户管理
+// @Accept  json
+// @Produce json
+// @Param email_verify body request.EmailVerify true "邮箱与验证码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/verifyemail [post]
+func (h *Handlers) VerifyEmail(ctx *gin.Context) {
+	var verify request.EmailVerify
+	if err := ctx.BindJSON(&verify); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &verify); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.VerifyEmail(ctx, verify); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "邮箱验证成功", nil))
+}
+
+// ResendVerifyEmail 重新发送验证邮件
+// @Summary 重新发送验证邮件
+// @Description 向未验证的邮箱重新发送验证码，无论邮箱是否存在都返回相同的结果
+// @Tags 账户管理
+// @Accept  json
+// @Produce json
+// @Param email_resend body request.EmailResend true "邮箱与图形验证码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/resendverify [post]
+func (h *Handlers) ResendVerifyEmail(ctx *gin.Context) {
+	var resend request.EmailResend
+	if err := ctx.BindJSON(&resend); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &resend); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := utils.NewCaptcha(h.db).Verify(resend.CheckCodeKey, resend.CheckCode, true); err != nil {
+		_ = ctx.Error(exception.ErrCheckCode)
+		return
+	}
+	if err := h.db.ResendVerifyEmail(ctx, resend); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "如果该邮箱已注册且未验证，验证邮件已发送", nil))
+}
+
+// ForgotPassword 忘记密码
+// @Summary 忘记密码
+// @Description 向邮箱发送一次性的重置密码令牌，无论邮箱是否存在都返回相同的结果
+// @Tags 账户管理
+// @Accept  json
+// @Produce json
+// @Param password_forgot body request.PasswordForgot true "邮箱与图形验证码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/forgotpassword [post]
+func (h *Handlers) ForgotPassword(ctx *gin.Context) {
+	var forgot request.PasswordForgot
+	if err := ctx.BindJSON(&forgot); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &forgot); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := utils.NewCaptcha(h.db).Verify(forgot.CheckCodeKey, forgot.CheckCode, true); err != nil {
+		_ = ctx.Error(exception.ErrCheckCode)
+		return
+	}
+	if err := h.db.ForgotPassword(ctx, forgot); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "如果该邮箱已注册，重置密码邮件已发送", nil))
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 使用邮件中的令牌设置新密码，成功后所有设备需要重新登录
+// @Tags 账户管理
+// @Accept  json
+// @Produce json
+// @Param password_reset body request.PasswordReset true "重置令牌与新密码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/resetpassword [post]
+func (h *Handlers) ResetPassword(ctx *gin.Context) {
+	var reset request.PasswordReset
+	if err := ctx.BindJSON(&reset); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &reset); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.ResetPassword(ctx, reset); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "密码已重置，请重新登录", nil))
 }
 
 // Login 处理用户登录请求。
 // @Summary 登陆
-// @Description 处理用户登录请求。
+// @Description 处理用户登录请求。在登录成功过的设备与IP上、且没有失败记录时可以不提供验证码，否则返回 1030 要求完成验证码。
 // @Tags 账户管理
 // @Accept  json
 // @Produce  json
 // @Param login body request.Login true "登录信息"
-// @Success 200 {object} response.Response "返回结果"
+// @Success 200 {object} response.Response{data=types.LoginResult} "返回结果"
 // @Failure 200 {object} response.Response "返回结果"
 // @Router /api/account/login [post]
 func (h *Handlers) Login(ctx *gin.Context) {
@@ -88,13 +204,28 @@ func (h *Handlers) Login(ctx *gin.Context) {
 		return
 	}
 
-	// 验证用户提供的验证码。
-	if err := utils.NewCaptcha(h.db).Verify(login.CheckCodeKey, login.CheckCode, true); err != nil {
-		// 如果验证码验证失败，返回错误信息并结束函数执行。
-		_ = ctx.Error(exception.ErrCheckCode)
+	// 检查IP与账号是否因失败次数过多被锁定，锁定期间不再消耗验证码。
+	if err := h.db.CheckLoginLock(ctx, ctx.ClientIP(), login.Email); err != nil {
+		h.db.RecordLoginAttempt(ctx, login.Email, login.Device, enums.LOGIN_LOCKED)
+		_ = ctx.Error(err)
 		return
 	}
 
+	// 可信设备与IP免除验证码，其余情况验证用户提供的验证码，验证码错误同样计入该IP的失败次数。
+	if h.db.CaptchaRequired(ctx, ctx.ClientIP(), login.Email, login.DeviceId) {
+		if login.CheckCodeKey == "" {
+			_ = ctx.Error(exception.ErrCaptchaRequired)
+			return
+		}
+		if err := utils.NewCaptcha(h.db).Verify(login.CheckCodeKey, login.CheckCode, true); err != nil {
+			h.db.RecordLoginFailure(ctx, ctx.ClientIP(), "")
+			h.db.RecordLoginAttempt(ctx, login.Email, login.Device, enums.LOGIN_CAPTCHA)
+			// 如果验证码验证失败，返回错误信息并结束函数执行。
+			_ = ctx.Error(exception.ErrCheckCode)
+			return
+		}
+	}
+
 	// 调用数据库接口进行用户登录验证。
 	if tokens, err := h.db.Login(ctx, login); err != nil {
 		// 如果登录验证失败，返回错误信息并结束函数执行。
@@ -106,6 +237,34 @@ func (h *Handlers) Login(ctx *gin.Context) {
 	}
 }
 
+// RefreshToken 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌，刷新令牌每次使用后都会更换，旧令牌再次使用会导致该设备的会话被注销
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param refresh_token body request.RefreshToken true "刷新令牌"
+// @Success 200 {object} response.Response{data=types.TokenPair} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/refresh [post]
+func (h *Handlers) RefreshToken(ctx *gin.Context) {
+	var refresh request.RefreshToken
+	if err := ctx.BindJSON(&refresh); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &refresh); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if tokens, err := h.db.RefreshToken(ctx, refresh); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "刷新成功", tokens))
+	}
+}
+
 // GetUserInfo 获取用户信息
 // @Summary 获取用户信息
 // @Description 获取用户信息
@@ -117,19 +276,11 @@ func (h *Handlers) Login(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "返回结果"
 // @Router /api/account/getuserinfo [get]
 func (h *Handlers) GetUserInfo(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if user, err := h.db.GetUserInfo(ctx, claims); err != nil {
 		_ = ctx.Error(err)
 		return
@@ -149,19 +300,11 @@ func (h *Handlers) GetUserInfo(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "返回结果"
 // @Router /api/account/logout [get]
 func (h *Handlers) Logout(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if err := h.db.Logout(ctx, claims); err != nil {
 		_ = ctx.Error(err)
 		return
@@ -171,7 +314,7 @@ func (h *Handlers) Logout(ctx *gin.Context) {
 
 // Search 搜索用户
 // @Summary 搜索用户
-// @Description 搜索用户
+// @Description 按邮箱精确搜索，或按用户名、昵称模糊搜索用户，返回分页的公开资料
 // @Tags 账户管理
 // @Accept  json
 // @Produce  json
@@ -181,32 +324,89 @@ func (h *Handlers) Logout(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "返回结果"
 // @Router /api/account/search [post]
 func (h *Handlers) Search(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
+	var userSearch request.UserSearch
+	if err := ctx.BindJSON(&userSearch); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
 		return
 	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
+	if err := validates.Validate(ctx, &userSearch); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
-	var userSearch request.UserSearch
-	if err := ctx.BindJSON(&userSearch); err != nil {
+	if users, err := h.db.Search(ctx, claims, userSearch); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "搜索成功", users))
+	}
+}
+
+// DeleteAccount 注销账号
+// @Summary 注销账号
+// @Description 验证密码（开启两步验证时还需要动态验证码）后注销账号，账号立即停用，宽限期内可以恢复，之后数据将被彻底清理
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param account_delete body request.AccountDelete true "密码与动态验证码"
+// @Success 200 {object} response.Response{data=types.AccountDeletion} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/delete [post]
+func (h *Handlers) DeleteAccount(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var remove request.AccountDelete
+	if err := ctx.BindJSON(&remove); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
 		return
 	}
-	if err := validates.Validate(ctx, &userSearch); err != nil {
+	if err := validates.Validate(ctx, &remove); err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if user, err := h.db.Search(ctx, userSearch); err != nil {
+	if deletion, err := h.db.DeleteAccount(ctx, claims, remove); err != nil {
 		_ = ctx.Error(err)
 		return
 	} else {
-		ctx.JSON(http.StatusOK, response.Success(0, "搜索成功", user))
+		ctx.JSON(http.StatusOK, response.Success(0, "账号已注销", deletion))
+	}
+}
+
+// RestoreAccount 恢复账号
+// @Summary 恢复账号
+// @Description 在宽限期内使用邮箱与密码恢复已注销的账号，恢复后需要重新登录
+// @Tags 账户管理
+// @Accept  json
+// @Produce json
+// @Param account_restore body request.AccountRestore true "邮箱、密码与图形验证码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/restore [post]
+func (h *Handlers) RestoreAccount(ctx *gin.Context) {
+	var restore request.AccountRestore
+	if err := ctx.BindJSON(&restore); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &restore); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := utils.NewCaptcha(h.db).Verify(restore.CheckCodeKey, restore.CheckCode, true); err != nil {
+		_ = ctx.Error(exception.ErrCheckCode)
+		return
+	}
+	if err := h.db.RestoreAccount(ctx, restore); err != nil {
+		_ = ctx.Error(err)
+		return
 	}
+	ctx.JSON(http.StatusOK, response.Success(0, "账号已恢复，请重新登录", nil))
 }
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// UnlockLogin 解除登录锁定
+// @Summary 解除登录锁定
+// @Description 管理员解除账号或IP因登录失败次数过多产生的锁定
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param login_unlock body request.LoginUnlock true "邮箱或IP"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/unlock [post]
+func (h *Handlers) UnlockLogin(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var unlock request.LoginUnlock
+	if err := ctx.BindJSON(&unlock); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &unlock); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.UnlockLogin(ctx, claims, unlock); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "解除锁定成功", nil))
+}
+
+// BanUser 封禁用户
+// @Summary 封禁用户
+// @Description 管理员封禁用户并注销其所有会话，截止时间为空时永久封禁
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param user_ban body request.UserBan true "用户ID、封禁原因与截止时间"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/ban [post]
+func (h *Handlers) BanUser(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var ban request.UserBan
+	if err := ctx.BindJSON(&ban); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &ban); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.BanUser(ctx, claims, ban); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "封禁成功", nil))
+}
+
+// UnbanUser 解除封禁
+// @Summary 解除封禁
+// @Description 管理员提前解除用户的封禁
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param user_unban body request.UserUnban true "用户ID"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/unban [post]
+func (h *Handlers) UnbanUser(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var unban request.UserUnban
+	if err := ctx.BindJSON(&unban); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &unban); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.UnbanUser(ctx, claims, unban); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "解除封禁成功", nil))
+}
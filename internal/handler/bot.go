@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CreateBot 创建机器人
+// @Summary 创建机器人
+// @Description 创建一个属于当前用户的机器人账号，机器人只能通过 API 密钥访问
+// @Tags 机器人
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param bot_create body request.BotCreate true "用户名与昵称"
+// @Success 200 {object} response.Response{data=types.BotInfo} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/bot/create [post]
+func (h *Handlers) CreateBot(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var create request.BotCreate
+	if err := ctx.BindJSON(&create); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &create); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if bot, err := h.db.CreateBot(ctx, claims, create); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "创建机器人成功", bot))
+	}
+}
+
+// ListBots 获取机器人列表
+// @Summary 获取机器人列表
+// @Description 列出当前用户创建的机器人及其 API 密钥，不包含密钥明文
+// @Tags 机器人
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response{data=[]types.BotInfo} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/bot/list [get]
+func (h *Handlers) ListBots(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if bots, err := h.db.ListBots(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取机器人成功", bots))
+	}
+}
+
+// CreateApiKey 创建API密钥
+// @Summary 创建API密钥
+// @Description 为机器人创建 API 密钥，权限范围目前只有 upload-file，密钥明文只返回这一次。请求时放在 X-Api-Key 请求头中
+// @Tags 机器人
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param api_key_create body request.ApiKeyCreate true "机器人ID、密钥名称与权限范围"
+// @Success 200 {object} response.Response{data=types.ApiKeySecret} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/bot/key/create [post]
+func (h *Handlers) CreateApiKey(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var create request.ApiKeyCreate
+	if err := ctx.BindJSON(&create); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &create); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if key, err := h.db.CreateApiKey(ctx, claims, create); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "创建API密钥成功", key))
+	}
+}
+
+// RotateApiKey 轮换API密钥
+// @Summary 轮换API密钥
+// @Description 生成新的密钥明文，权限范围不变，旧的密钥立即失效
+// @Tags 机器人
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param api_key_target body request.ApiKeyTarget true "密钥ID"
+// @Success 200 {object} response.Response{data=types.ApiKeySecret} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/bot/key/rotate [post]
+func (h *Handlers) RotateApiKey(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var target request.ApiKeyTarget
+	if err := ctx.BindJSON(&target); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &target); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if key, err := h.db.RotateApiKey(ctx, claims, target); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "轮换API密钥成功", key))
+	}
+}
+
+// RevokeApiKey 吊销API密钥
+// @Summary 吊销API密钥
+// @Description 吊销机器人的 API 密钥
+// @Tags 机器人
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param api_key_target body request.ApiKeyTarget true "密钥ID"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/bot/key/revoke [post]
+func (h *Handlers) RevokeApiKey(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var target request.ApiKeyTarget
+	if err := ctx.BindJSON(&target); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &target); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.RevokeApiKey(ctx, claims, target); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "吊销API密钥成功", nil))
+}
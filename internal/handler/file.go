@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"Gin-IM/pkg/defines"
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/request"
 	"Gin-IM/pkg/response"
@@ -23,19 +22,11 @@ import (
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/file/upload [post]
 func (h *Handlers) UploadFile(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var upload request.FileUpload
 	if err := ctx.BindJSON(&upload); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -65,19 +56,11 @@ func (h *Handlers) UploadFile(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败受到"
 // @Router /api/file/download [post]
 func (h *Handlers) GetShortUrl(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var fileDownload request.FileDownload
 	if err := ctx.BindJSON(&fileDownload); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -107,19 +90,11 @@ func (h *Handlers) GetShortUrl(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/file/delete [post]
 func (h *Handlers) DeleteFile(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var fileDeletes request.FileDeletes
 	if err := ctx.BindJSON(&fileDeletes); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -149,19 +124,11 @@ func (h *Handlers) DeleteFile(ctx *gin.Context) {
 // @Router /api/file/merge [post]
 func (h *Handlers) MergeFile(ctx *gin.Context) {
 	var merge request.FileMerge
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if err := ctx.BindJSON(&merge); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
 		return
@@ -177,6 +144,40 @@ func (h *Handlers) MergeFile(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response.Success(0, "合并成功", nil))
 }
 
+// ListFiles godoc
+// @Summary 获取文件列表
+// @Description 获取当前用户上传的文件，不包括回收站中的文件。可以按类型（image、video、audio、file）、状态与文件名筛选，按上传时间、文件名或大小排序，使用上一页返回的 nextCursor 获取下一页
+// @Tags 文件管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param fileList body request.FileList true "筛选条件、排序方式与游标"
+// @Success 200 {object} response.Response{data=types.FileList} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/file/list [post]
+func (h *Handlers) ListFiles(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var list request.FileList
+	if err := ctx.BindJSON(&list); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &list); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if files, err := h.db.ListFiles(ctx, claims, list); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取成功", files))
+	}
+}
+
 // GetTrash godoc
 // @Summary 获取回收站文件列表
 // @Description 获取回收站文件列表
@@ -188,19 +189,11 @@ func (h *Handlers) MergeFile(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/file/trash [get]
 func (h *Handlers) GetTrash(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	recoveries := h.db.GetFileTrash(ctx, claims)
 	ctx.JSON(http.StatusOK, response.Success(0, "获取成功", recoveries))
 }
@@ -218,19 +211,11 @@ func (h *Handlers) GetTrash(ctx *gin.Context) {
 // @Router /api/file/recovery [post]
 func (h *Handlers) RecoveryFile(ctx *gin.Context) {
 	var recoveries request.FileRecoveryList
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if err := ctx.BindJSON(&recoveries); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
 		return
@@ -258,19 +243,6 @@ func (h *Handlers) RecoveryFile(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/file/pushparts [post]
 func (h *Handlers) PushPartsInfo(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
-	if err != nil {
-		_ = ctx.Error(err)
-		return
-	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var parts request.PartInfo
 	if err := ctx.BindJSON(&parts); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
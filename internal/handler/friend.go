@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"Gin-IM/pkg/defines"
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/request"
 	"Gin-IM/pkg/response"
 	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/utils"
 	"Gin-IM/pkg/validates"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -23,19 +23,11 @@ import (
 // @Failure 200 {object} response.Response{data=string} "失败"
 // @Router /api/friend/add [post]
 func (h *Handlers) AddFriend(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		err = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		err = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		err = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var friendRequest request.FriendRequest
 	if err := ctx.BindJSON(&friendRequest); err != nil {
 		err = ctx.Error(exception.ErrBadRequest)
@@ -64,19 +56,11 @@ func (h *Handlers) AddFriend(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/list [get]
 func (h *Handlers) GetFriendList(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if friends, err := h.db.GetFriendList(ctx, claims); err != nil {
 		_ = ctx.Error(err)
 		return
@@ -97,19 +81,11 @@ func (h *Handlers) GetFriendList(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/black [post]
 func (h *Handlers) AddToBlackList(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var friendRequest request.FriendRequest
 	if err := ctx.BindJSON(&friendRequest); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -137,19 +113,11 @@ func (h *Handlers) AddToBlackList(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/blacklist [get]
 func (h *Handlers) GetBlackList(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	if blackList, err := h.db.GetBlackList(ctx, claims); err != nil {
 		_ = ctx.Error(err)
 		return
@@ -170,19 +138,11 @@ func (h *Handlers) GetBlackList(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/cancelblack [post]
 func (h *Handlers) CancelBlack(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var friendRequest request.FriendRequest
 	if err := ctx.BindJSON(&friendRequest); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -211,19 +171,11 @@ func (h *Handlers) CancelBlack(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/delete [post]
 func (h *Handlers) DeleteFriend(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
-		return
-	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
-		return
-	}
 	var friendRequest request.FriendRequest
 	if err := ctx.BindJSON(&friendRequest); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -252,19 +204,97 @@ func (h *Handlers) DeleteFriend(ctx *gin.Context) {
 // @Failure 200 {object} response.Response "失败"
 // @Router /api/friend/agree [post]
 func (h *Handlers) AgreeFriendRequest(ctx *gin.Context) {
-	claims, err := token.ExtractClaims(ctx)
+	claims, err := token.CurrentClaims(ctx)
 	if err != nil {
 		_ = ctx.Error(err)
 		return
 	}
-	if len(claims.UserId) == 0 {
-		_ = ctx.Error(exception.ErrTokenEmpty)
+	var friendRequest request.FriendRequest
+	if err := ctx.BindJSON(&friendRequest); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &friendRequest); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.AgreeFriendRequest(ctx, claims, friendRequest); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
-	if str := h.db.GetValue(ctx, defines.USER_TOKEN_KEY+claims.UserId); str == "" {
-		_ = ctx.Error(exception.ErrLoginTimeout)
+	ctx.JSON(http.StatusOK, response.Success(0, "同意好友请求成功", nil))
+}
+
+// GetFriendSuggestions 获取可能认识的人
+// @Summary 获取可能认识的人
+// @Description 根据共同好友数量推荐可能认识的人
+// @Tags 好友
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Success 200 {object} response.Response{data=[]types.FriendSuggestion} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/friend/suggest [get]
+func (h *Handlers) GetFriendSuggestions(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
+	if suggestions, err := h.db.GetFriendSuggestions(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取推荐成功", suggestions))
+	}
+}
+
+// SyncFriendList 增量同步好友列表
+// @Summary 增量同步好友列表
+// @Description 根据客户端的通讯录版本号返回之后的变更，版本号为0时返回完整列表
+// @Tags 好友
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param friend_sync body request.FriendSync true "通讯录版本"
+// @Success 200 {object} response.Response{data=types.FriendSync} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/friend/sync [post]
+func (h *Handlers) SyncFriendList(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var friendSync request.FriendSync
+	if err := ctx.BindJSON(&friendSync); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &friendSync); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if result, err := h.db.SyncFriendList(ctx, claims, friendSync); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "同步成功", result))
+	}
+}
+
+// GetFriendQuestion 获取好友验证问题
+// @Summary 获取好友验证问题
+// @Description 对方要求回答问题才能添加好友时，返回需要回答的问题
+// @Tags 好友
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param friend_request body request.FriendRequest true "好友信息"
+// @Success 200 {object} response.Response{data=string} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/friend/question [post]
+func (h *Handlers) GetFriendQuestion(ctx *gin.Context) {
 	var friendRequest request.FriendRequest
 	if err := ctx.BindJSON(&friendRequest); err != nil {
 		_ = ctx.Error(exception.ErrBadRequest)
@@ -274,9 +304,66 @@ func (h *Handlers) AgreeFriendRequest(ctx *gin.Context) {
 		_ = ctx.Error(err)
 		return
 	}
-	if err := h.db.AgreeFriendRequest(ctx, claims, friendRequest); err != nil {
+	if question, err := h.db.GetFriendQuestion(ctx, friendRequest); err != nil {
 		_ = ctx.Error(err)
 		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取验证问题成功", question))
+	}
+}
+
+// GetDiscoverySalt 获取通讯录发现的盐值
+// @Summary 获取通讯录发现的盐值
+// @Description 客户端使用该盐值对通讯录中的邮箱（去除首尾空白并转为小写）计算 SHA-256 哈希后再上传，服务端未配置盐值时通讯录发现不可用
+// @Tags 好友
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Success 200 {object} response.Response{data=string} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/friend/discover/salt [get]
+func (h *Handlers) GetDiscoverySalt(ctx *gin.Context) {
+	if utils.DiscoverySalt() == "" {
+		_ = ctx.Error(exception.ErrFeatureDisabled)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "获取盐值成功", utils.DiscoverySalt()))
+}
+
+// DiscoverContacts 通讯录发现
+// @Summary 通讯录发现
+// @Description 上传通讯录中邮箱的加盐哈希，返回其中已注册且允许通过邮箱被发现的用户，按上传数量限流
+// @Tags 好友
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param contact_discover body request.ContactDiscover true "邮箱哈希列表"
+// @Success 200 {object} response.Response{data=[]types.DiscoveredContact} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/friend/discover [post]
+func (h *Handlers) DiscoverContacts(ctx *gin.Context) {
+	if utils.DiscoverySalt() == "" {
+		_ = ctx.Error(exception.ErrFeatureDisabled)
+		return
+	}
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var discover request.ContactDiscover
+	if err := ctx.BindJSON(&discover); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &discover); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if contacts, err := h.db.DiscoverContacts(ctx, claims, discover); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "查询成功", contacts))
 	}
-	ctx.JSON(http.StatusOK, response.Success(0, "同意好友请求成功", nil))
 }
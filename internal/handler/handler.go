@@ -2,9 +2,12 @@ package handler
 
 import (
 	"Gin-IM/internal/database"
+	"Gin-IM/pkg/types"
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
 )
 
 type Handlers struct {
@@ -27,3 +30,66 @@ func (h *Handlers) InitDBTables(models ...any) error {
 	}
 	return h.db.GetDB(nil).AutoMigrate(models...)
 }
+
+// RefreshFriendSuggestions 定时刷新好友推荐缓存
+func (h *Handlers) RefreshFriendSuggestions(ctx context.Context) {
+	if err := h.db.RefreshFriendSuggestions(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("刷新好友推荐失败")
+	}
+}
+
+// BackfillEmailHash 为历史用户补充或按当前盐值重新计算通讯录发现使用的邮箱哈希
+func (h *Handlers) BackfillEmailHash(ctx context.Context) {
+	if err := h.db.BackfillEmailHash(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("补充邮箱哈希失败")
+	}
+}
+
+// BackfillFileMetadata 为历史文件补充文件类型、大小与内容类型
+func (h *Handlers) BackfillFileMetadata(ctx context.Context) {
+	if err := h.db.BackfillFileMetadata(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("补充文件元数据失败")
+	}
+}
+
+// InitRoles 同步内置角色与权限，并按 ADMIN 中配置的邮箱同步本地账号的管理员角色
+func (h *Handlers) InitRoles(ctx context.Context) {
+	if err := h.db.SeedRoles(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("同步角色与权限失败")
+		return
+	}
+	if err := h.db.PromoteAdmins(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("设置管理员失败")
+	}
+}
+
+// PurgeDeletedAccounts 定时清理宽限期已过的注销账号
+func (h *Handlers) PurgeDeletedAccounts(ctx context.Context) {
+	if err := h.db.PurgeDeletedAccounts(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("清理注销账号失败")
+	}
+}
+
+// PurgeLoginHistory 定时清理超过保留天数的登录记录
+func (h *Handlers) PurgeLoginHistory(ctx context.Context) {
+	if err := h.db.PurgeLoginHistory(ctx); err != nil {
+		log.Logger.Error().Err(err).Msg("清理登录记录失败")
+	}
+}
+
+// LoadApiKeyPrincipal 供 API 密钥中间件校验机器人的密钥与路由所需的权限范围
+func (h *Handlers) LoadApiKeyPrincipal(ctx *gin.Context, key, scope string) (*types.Principal, error) {
+	return h.db.AuthenticateApiKey(ctx, key, scope, ctx.ClientIP())
+}
+
+// LoadPrincipal 供 JWT 中间件检查令牌对应的会话是否仍然有效，并加载用户的权限
+func (h *Handlers) LoadPrincipal(ctx *gin.Context, claims *types.GIClaims) (*types.Principal, error) {
+	if err := h.db.CheckSession(ctx, claims); err != nil {
+		return nil, err
+	}
+	permissions, err := h.db.UserPermissions(ctx, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Principal{Claims: claims, Permissions: permissions}, nil
+}
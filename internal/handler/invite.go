@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CreateInvite 生成邀请二维码
+// @Summary 生成邀请二维码
+// @Description 生成带签名与有效期的“加我好友”令牌，并以 base64 编码的 PNG 二维码返回
+// @Tags 邀请
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param invite_create body request.InviteCreate true "邀请类型"
+// @Success 200 {object} response.Response{data=types.InviteQRCode} "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/invite/create [post]
+func (h *Handlers) CreateInvite(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var invite request.InviteCreate
+	if err := ctx.BindJSON(&invite); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &invite); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if qrCode, err := h.db.CreateInvite(ctx, claims, invite); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "生成二维码成功", qrCode))
+	}
+}
+
+// RedeemInvite 使用邀请二维码
+// @Summary 使用邀请二维码
+// @Description 校验扫码得到的令牌签名与有效期，并发起好友申请
+// @Tags 邀请
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer Token令牌"
+// @Param invite_redeem body request.InviteRedeem true "邀请令牌"
+// @Success 200 {object} response.Response "成功"
+// @Failure 200 {object} response.Response "失败"
+// @Router /api/invite/redeem [post]
+func (h *Handlers) RedeemInvite(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var redeem request.InviteRedeem
+	if err := ctx.BindJSON(&redeem); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &redeem); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.RedeemInvite(ctx, claims, redeem); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "发送好友请求", nil))
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"Gin-IM/pkg/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetJWKS 获取令牌验签公钥
+// @Summary 获取令牌验签公钥
+// @Description 以 JWKS 格式返回所有有效的验签公钥，其他服务按令牌头部的 kid 选择公钥在本地校验令牌
+// @Tags 账户管理
+// @Produce  json
+// @Success 200 {object} types.JWKS "返回结果"
+// @Router /.well-known/jwks.json [get]
+func (h *Handlers) GetJWKS(ctx *gin.Context) {
+	// 返回标准 JWKS 文档而不是统一响应格式，便于通用的 JWT 库直接使用
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, token.JWKS())
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ListLoginHistory 获取登录记录
+// @Summary 获取登录记录
+// @Description 分页列出当前用户的登录尝试，包括时间、IP、User-Agent、设备、是否成功与失败原因，suspicious 表示来自新设备或新网段的登录
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param login_history body request.LoginHistory true "分页信息"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/signins [post]
+func (h *Handlers) ListLoginHistory(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var history request.LoginHistory
+	if err := ctx.BindJSON(&history); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &history); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if records, err := h.db.ListLoginHistory(ctx, claims, history); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取登录记录成功", records))
+	}
+}
+
+// AdminLoginHistory 查询登录记录
+// @Summary 查询登录记录
+// @Description 管理员按用户ID、邮箱或IP查询所有用户的登录尝试，可以只查看可疑的登录
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param login_history body request.AdminLoginHistory true "查询条件与分页信息"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/signins [post]
+func (h *Handlers) AdminLoginHistory(ctx *gin.Context) {
+	var history request.AdminLoginHistory
+	if err := ctx.BindJSON(&history); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &history); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if records, err := h.db.AdminLoginHistory(ctx, history); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取登录记录成功", records))
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// OAuthAuthorize 开始第三方登录
+// @Summary 开始第三方登录
+// @Description 返回 OpenID Connect 提供方的授权地址（授权码模式 + PKCE），客户端跳转到该地址完成授权
+// @Tags 第三方登录
+// @Accept  json
+// @Produce  json
+// @Param provider path string true "身份提供方名称"
+// @Param device query string false "设备名称"
+// @Success 200 {object} response.Response{data=types.OAuthAuthorize} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/oauth/{provider}/authorize [get]
+func (h *Handlers) OAuthAuthorize(ctx *gin.Context) {
+	var authorize request.OAuthAuthorize
+	if err := ctx.BindQuery(&authorize); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &authorize); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if result, err := h.db.OAuthAuthorize(ctx, ctx.Param("provider"), authorize); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取授权地址成功", result))
+	}
+}
+
+// OAuthCallback 完成第三方登录
+// @Summary 完成第三方登录
+// @Description 提交提供方回调中的 state 与授权码，首次登录时创建账号（提供方允许时按邮箱关联已有账号），开启了两步验证时返回登录挑战
+// @Tags 第三方登录
+// @Accept  json
+// @Produce  json
+// @Param oauth_callback body request.OAuthCallback true "state与授权码"
+// @Success 200 {object} response.Response{data=types.LoginResult} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/oauth/callback [post]
+func (h *Handlers) OAuthCallback(ctx *gin.Context) {
+	var callback request.OAuthCallback
+	if err := ctx.BindJSON(&callback); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &callback); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if result, err := h.db.OAuthCallback(ctx, callback); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "登录成功", result))
+	}
+}
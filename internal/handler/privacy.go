@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetPrivacy 获取隐私设置
+// @Summary 获取隐私设置
+// @Description 获取当前用户的查找方式、好友申请策略与最后在线时间可见范围
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response{data=types.Privacy} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/privacy [get]
+func (h *Handlers) GetPrivacy(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if privacy, err := h.db.GetPrivacy(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取隐私设置成功", privacy))
+	}
+}
+
+// UpdatePrivacy 修改隐私设置
+// @Summary 修改隐私设置
+// @Description 修改当前用户的查找方式、好友申请策略与最后在线时间可见范围
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param privacy body request.Privacy true "隐私设置"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/privacy [post]
+func (h *Handlers) UpdatePrivacy(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var privacy request.Privacy
+	if err := ctx.BindJSON(&privacy); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &privacy); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.UpdatePrivacy(ctx, claims, privacy); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "修改隐私设置成功", nil))
+}
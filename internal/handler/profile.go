@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"Gin-IM/pkg/defines"
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/utils"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// UpdateProfile 修改个人资料
+// @Summary 修改个人资料
+// @Description 修改用户名与昵称，字段为空时保持不变
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param profile body request.ProfileUpdate true "个人资料"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/profile [post]
+func (h *Handlers) UpdateProfile(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var profile request.ProfileUpdate
+	if err := ctx.BindJSON(&profile); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &profile); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.UpdateProfile(ctx, claims, profile); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "修改成功", nil))
+}
+
+// ChangePassword 修改密码
+// @Summary 修改密码
+// @Description 验证原密码后设置新密码，当前设备以外的会话都会被注销
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param password_change body request.PasswordChange true "原密码与新密码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/password [post]
+func (h *Handlers) ChangePassword(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var change request.PasswordChange
+	if err := ctx.BindJSON(&change); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &change); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.ChangePassword(ctx, claims, change); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "密码修改成功", nil))
+}
+
+// UploadAvatar 上传头像
+// @Summary 上传头像
+// @Description 上传 jpeg/png/gif/webp 图片，服务端按裁剪区域裁剪为正方形并缩放后保存。返回的头像对象名称可以通过 /api/{avatar} 访问
+// @Tags 账户管理
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param file formData file true "头像图片"
+// @Param x formData int false "裁剪区域左上角横坐标"
+// @Param y formData int false "裁剪区域左上角纵坐标"
+// @Param size formData int false "裁剪区域边长，为0时从中心裁剪"
+// @Success 200 {object} response.Response{data=string} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/avatar [post]
+func (h *Handlers) UploadAvatar(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var crop request.AvatarCrop
+	if err := ctx.ShouldBind(&crop); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &crop); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil || fileHeader.Size > defines.AVATAR_MAX_SIZE {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = ctx.Error(exception.ErrUploadFile)
+		return
+	}
+	defer file.Close()
+	data, err := utils.ReadLimited(file, defines.AVATAR_MAX_SIZE)
+	if err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if avatar, err := h.db.UpdateAvatar(ctx, claims, data, crop); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "上传成功", avatar))
+	}
+}
+
+// GetAvatar 获取头像
+// @Summary 获取头像
+// @Description 重定向到头像图片的预签名地址，头像对象名称形如 avatar/{userId}/{name}
+// @Tags 账户管理
+// @Param name path string true "头像对象名称中 avatar/ 之后的部分"
+// @Success 302 "重定向到头像图片"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/avatar/{name} [get]
+func (h *Handlers) GetAvatar(ctx *gin.Context) {
+	objectName := defines.AVATAR_PREFIX + strings.TrimPrefix(ctx.Param("name"), "/")
+	if url, err := h.db.GetAvatarUrl(ctx, objectName); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.Redirect(http.StatusFound, url)
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ListRoles 获取角色列表
+// @Summary 获取角色列表
+// @Description 列出所有角色及其拥有的权限标识
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response{data=[]types.RoleInfo} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/roles [get]
+func (h *Handlers) ListRoles(ctx *gin.Context) {
+	if roles, err := h.db.ListRoles(ctx); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取角色成功", roles))
+	}
+}
+
+// SaveRole 保存角色
+// @Summary 保存角色
+// @Description 创建角色或替换已有角色的说明与权限，内置的 admin 角色不能修改
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param role_save body request.RoleSave true "角色名称、说明与权限标识"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/role [post]
+func (h *Handlers) SaveRole(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var save request.RoleSave
+	if err := ctx.BindJSON(&save); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &save); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.SaveRole(ctx, claims, save); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "保存角色成功", nil))
+}
+
+// AssignRole 授予角色
+// @Summary 授予角色
+// @Description 为用户授予角色，权限在下一次请求时生效
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param role_assign body request.RoleAssign true "用户ID与角色名称"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/role/assign [post]
+func (h *Handlers) AssignRole(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var assign request.RoleAssign
+	if err := ctx.BindJSON(&assign); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &assign); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.AssignRole(ctx, claims, assign); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "授予角色成功", nil))
+}
+
+// RevokeRole 撤销角色
+// @Summary 撤销角色
+// @Description 撤销用户的角色，不能撤销自己的 admin 角色
+// @Tags 管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param role_revoke body request.RoleAssign true "用户ID与角色名称"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/admin/role/revoke [post]
+func (h *Handlers) RevokeRole(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var revoke request.RoleAssign
+	if err := ctx.BindJSON(&revoke); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &revoke); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.RevokeRole(ctx, claims, revoke); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "撤销角色成功", nil))
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ListSessions 获取登录设备列表
+// @Summary 获取登录设备列表
+// @Description 列出当前用户所有有效的会话，包括设备名称、IP、User-Agent、登录时间与最后活跃时间
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response{data=[]types.SessionInfo} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/sessions [get]
+func (h *Handlers) ListSessions(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if sessions, err := h.db.ListSessions(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "获取设备列表成功", sessions))
+	}
+}
+
+// RevokeSession 注销指定设备
+// @Summary 注销指定设备
+// @Description 远程注销当前用户的一个会话，该会话的令牌立即失效
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param session_revoke body request.SessionRevoke true "会话ID"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/sessions/revoke [post]
+func (h *Handlers) RevokeSession(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var revoke request.SessionRevoke
+	if err := ctx.BindJSON(&revoke); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &revoke); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.RevokeSession(ctx, claims, revoke); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "注销成功", nil))
+}
+
+// RevokeOtherSessions 注销其他所有设备
+// @Summary 注销其他所有设备
+// @Description 注销当前设备以外的所有会话
+// @Tags 账户管理
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/sessions/revokeothers [post]
+func (h *Handlers) RevokeOtherSessions(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.RevokeOtherSessions(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "注销成功", nil))
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/request"
+	"Gin-IM/pkg/response"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/validates"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// SetupTwoFactor 生成两步验证密钥
+// @Summary 生成两步验证密钥
+// @Description 生成 TOTP 密钥并返回 otpauth 地址与二维码，使用动态验证码确认之前不会生效
+// @Tags 两步验证
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Success 200 {object} response.Response{data=types.TwoFactorSetup} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/2fa/setup [post]
+func (h *Handlers) SetupTwoFactor(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if setup, err := h.db.SetupTwoFactor(ctx, claims); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "生成密钥成功", setup))
+	}
+}
+
+// EnableTwoFactor 开启两步验证
+// @Summary 开启两步验证
+// @Description 使用身份验证器上的第一个动态验证码确认密钥，返回只显示一次的恢复码
+// @Tags 两步验证
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param two_factor_code body request.TwoFactorCode true "动态验证码"
+// @Success 200 {object} response.Response{data=[]string} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/2fa/enable [post]
+func (h *Handlers) EnableTwoFactor(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var confirm request.TwoFactorCode
+	if err := ctx.BindJSON(&confirm); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &confirm); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if codes, err := h.db.EnableTwoFactor(ctx, claims, confirm); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "两步验证已开启，请妥善保存恢复码", codes))
+	}
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 提供密码与动态验证码（或恢复码）后关闭两步验证
+// @Tags 两步验证
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param two_factor_disable body request.TwoFactorDisable true "密码与验证码"
+// @Success 200 {object} response.Response "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/2fa/disable [post]
+func (h *Handlers) DisableTwoFactor(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var disable request.TwoFactorDisable
+	if err := ctx.BindJSON(&disable); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &disable); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := h.db.DisableTwoFactor(ctx, claims, disable); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response.Success(0, "两步验证已关闭", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 使用动态验证码确认后生成新的恢复码，旧的恢复码全部作废
+// @Tags 两步验证
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Bearer token令牌"
+// @Param two_factor_code body request.TwoFactorCode true "动态验证码"
+// @Success 200 {object} response.Response{data=[]string} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/2fa/recovery [post]
+func (h *Handlers) RegenerateRecoveryCodes(ctx *gin.Context) {
+	claims, err := token.CurrentClaims(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var confirm request.TwoFactorCode
+	if err := ctx.BindJSON(&confirm); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &confirm); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if codes, err := h.db.RegenerateRecoveryCodes(ctx, claims, confirm); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "恢复码已重新生成", codes))
+	}
+}
+
+// VerifyTwoFactorLogin 两步验证登录
+// @Summary 两步验证登录
+// @Description 使用登录接口返回的登录挑战与动态验证码（或恢复码）完成登录
+// @Tags 两步验证
+// @Accept  json
+// @Produce  json
+// @Param two_factor_login body request.TwoFactorLogin true "登录挑战与验证码"
+// @Success 200 {object} response.Response{data=types.TokenPair} "返回结果"
+// @Failure 200 {object} response.Response "返回结果"
+// @Router /api/account/2fa/verify [post]
+func (h *Handlers) VerifyTwoFactorLogin(ctx *gin.Context) {
+	var verify request.TwoFactorLogin
+	if err := ctx.BindJSON(&verify); err != nil {
+		_ = ctx.Error(exception.ErrBadRequest)
+		return
+	}
+	if err := validates.Validate(ctx, &verify); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if tokens, err := h.db.VerifyTwoFactorLogin(ctx, verify); err != nil {
+		_ = ctx.Error(err)
+		return
+	} else {
+		ctx.JSON(http.StatusOK, response.Success(0, "登录成功", tokens))
+	}
+}
@@ -0,0 +1,184 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	goldap "github.com/go-ldap/ldap/v3"
+	_ "github.com/joho/godotenv/autoload"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config LDAP 认证配置，LDAP_URL 为空时不启用
+type Config struct {
+	Url             string
+	StartTLS        bool
+	UserDnTemplate  string
+	EmailAttr       string
+	NameAttr        string
+	GroupBaseDn     string
+	GroupMemberAttr string
+	AdminGroups     []string
+	Timeout         time.Duration
+	TLSConfig       *tls.Config
+}
+
+// User 认证通过的目录用户
+type User struct {
+	DN     string
+	Email  string
+	Name   string
+	Groups []string
+	Admin  bool
+}
+
+// LoadConfig 根据环境变量加载 LDAP 配置，未配置 LDAP_URL 时返回 nil
+// LDAP_USER_DN_TEMPLATE 中的 {email} 替换为登录邮箱，{username} 替换为邮箱 @ 之前的部分，
+// 例如 uid={username},ou=people,dc=example,dc=com。
+// 配置了 LDAP_GROUP_BASE_DN 时在该目录下按 LDAP_GROUP_MEMBER_ATTR（默认 member）搜索用户所在的组，
+// 否则读取用户条目的 memberOf 属性。LDAP_ADMIN_GROUPS 为分号分隔的组 DN 或 CN（DN 本身包含逗号），属于其中任意一组的用户是管理员。
+func LoadConfig() *Config {
+	if os.Getenv("LDAP_URL") == "" {
+		return nil
+	}
+	startTLS, _ := strconv.ParseBool(os.Getenv("LDAP_STARTTLS"))
+	config := &Config{
+		Url:             os.Getenv("LDAP_URL"),
+		StartTLS:        startTLS,
+		UserDnTemplate:  os.Getenv("LDAP_USER_DN_TEMPLATE"),
+		EmailAttr:       envOr("LDAP_EMAIL_ATTR", "mail"),
+		NameAttr:        envOr("LDAP_NAME_ATTR", "displayName"),
+		GroupBaseDn:     os.Getenv("LDAP_GROUP_BASE_DN"),
+		GroupMemberAttr: envOr("LDAP_GROUP_MEMBER_ATTR", "member"),
+		Timeout:         10 * time.Second,
+	}
+	for _, group := range strings.Split(os.Getenv("LDAP_ADMIN_GROUPS"), ";") {
+		if group = strings.TrimSpace(group); group != "" {
+			config.AdminGroups = append(config.AdminGroups, group)
+		}
+	}
+	return config
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+var (
+	ErrInvalidCredentials = errors.New("ldap: invalid credentials")
+	ErrUnexpectedResponse = errors.New("ldap: unexpected response")
+)
+
+// dial 连接 ldap:// 或 ldaps:// 地址，配置了 StartTLS 时在 ldap:// 连接上升级为 TLS
+func (c *Config) dial() (*goldap.Conn, error) {
+	u, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return nil, errors.New("ldap: unsupported scheme " + u.Scheme)
+	}
+	tlsConfig := c.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = u.Hostname()
+	}
+	conn, err := goldap.DialURL(c.Url, goldap.DialWithDialer(&net.Dialer{Timeout: c.Timeout}), goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(c.Timeout)
+	if c.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// Authenticate 使用登录邮箱对应的 DN 与密码绑定，成功后读取用户属性与所在的组
+// 空密码在 LDAP 中表示匿名绑定，服务器会返回成功，因此在连接之前直接拒绝
+func (c *Config) Authenticate(ctx context.Context, email, password string) (*User, error) {
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	username, _, _ := strings.Cut(email, "@")
+	dn := strings.NewReplacer("{email}", goldap.EscapeDN(email), "{username}", goldap.EscapeDN(username)).Replace(c.UserDnTemplate)
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// 请求被取消时关闭连接，正在等待的操作会立即返回
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	if err := conn.Bind(dn, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	result, err := conn.Search(goldap.NewSearchRequest(dn, goldap.ScopeBaseObject, goldap.NeverDerefAliases, 1, int(c.Timeout/time.Second), false,
+		"(objectClass=*)", []string{c.EmailAttr, c.NameAttr, "memberOf"}, nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Entries) != 1 {
+		return nil, ErrUnexpectedResponse
+	}
+	entry := result.Entries[0]
+	user := &User{
+		DN:     entry.DN,
+		Email:  entry.GetEqualFoldAttributeValue(c.EmailAttr),
+		Name:   entry.GetEqualFoldAttributeValue(c.NameAttr),
+		Groups: entry.GetEqualFoldAttributeValues("memberOf"),
+	}
+	if user.DN == "" {
+		user.DN = dn
+	}
+	if c.GroupBaseDn != "" {
+		filter := "(" + c.GroupMemberAttr + "=" + goldap.EscapeFilter(user.DN) + ")"
+		groups, err := conn.Search(goldap.NewSearchRequest(c.GroupBaseDn, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, int(c.Timeout/time.Second), false,
+			filter, []string{"cn"}, nil))
+		if err != nil {
+			return nil, err
+		}
+		user.Groups = user.Groups[:0]
+		for _, group := range groups.Entries {
+			user.Groups = append(user.Groups, group.DN)
+		}
+	}
+	user.Admin = c.isAdmin(user.Groups)
+	return user, nil
+}
+
+// isAdmin 组 DN 或其第一个 RDN 的值与 LDAP_ADMIN_GROUPS 中任意一项匹配时为管理员，不区分大小写
+func (c *Config) isAdmin(groups []string) bool {
+	for _, group := range groups {
+		cn := group
+		if rdn, _, ok := strings.Cut(group, ","); ok {
+			cn = rdn
+		}
+		if _, value, ok := strings.Cut(cn, "="); ok {
+			cn = value
+		}
+		for _, admin := range c.AdminGroups {
+			if strings.EqualFold(admin, group) || strings.EqualFold(admin, cn) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,347 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// 进程内目录服务使用的 BER 编解码，客户端使用 go-ldap，这里只用于在测试中实现服务端
+
+// LDAP 协议操作的应用标签
+const (
+	opBindRequest       = 0
+	opBindResponse      = 1
+	opUnbindRequest     = 2
+	opSearchRequest     = 3
+	opSearchResultEntry = 4
+	opSearchResultDone  = 5
+	opSearchResultRef   = 19
+)
+
+// 搜索范围
+const (
+	scopeBase    = 0
+	scopeSubtree = 2
+)
+
+const (
+	resultSuccess            = 0
+	resultInvalidCredentials = 49
+)
+
+// BER 编码中用到的类别
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+)
+
+// BER 通用类型标签
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x10
+	tagSet         = 0x11
+)
+
+// maxPacketSize 单个 LDAP 消息的最大长度，防止异常的长度字段耗尽内存
+const maxPacketSize = 4 << 20
+
+var errMalformed = errors.New("ldap: malformed packet")
+
+// packet BER 编码的一个 TLV 节点，构造类型使用 Children，基本类型使用 Value
+type packet struct {
+	Class       byte
+	Constructed bool
+	Tag         byte
+	Value       []byte
+	Children    []*packet
+}
+
+func newSequence(children ...*packet) *packet {
+	return &packet{Class: classUniversal, Constructed: true, Tag: tagSequence, Children: children}
+}
+
+func newString(value string) *packet {
+	return &packet{Class: classUniversal, Tag: tagOctetString, Value: []byte(value)}
+}
+
+func newInteger(tag byte, value int64) *packet {
+	// 最短的二进制补码表示
+	var buf []byte
+	for {
+		buf = append([]byte{byte(value)}, buf...)
+		if (value >= -128 && value < 128) || len(buf) == 8 {
+			break
+		}
+		value >>= 8
+	}
+	return &packet{Class: classUniversal, Tag: tag, Value: buf}
+}
+
+func newBoolean(value bool) *packet {
+	if value {
+		return &packet{Class: classUniversal, Tag: tagBoolean, Value: []byte{0xff}}
+	}
+	return &packet{Class: classUniversal, Tag: tagBoolean, Value: []byte{0x00}}
+}
+
+// encode 按 DER 规则编码，长度使用最短形式
+func (p *packet) encode() []byte {
+	content := p.Value
+	if p.Constructed {
+		content = nil
+		for _, child := range p.Children {
+			content = append(content, child.encode()...)
+		}
+	}
+	identifier := p.Class | p.Tag
+	if p.Constructed {
+		identifier |= 0x20
+	}
+	out := []byte{identifier}
+	length := len(content)
+	if length < 0x80 {
+		out = append(out, byte(length))
+	} else {
+		var lengthBytes []byte
+		for length > 0 {
+			lengthBytes = append([]byte{byte(length)}, lengthBytes...)
+			length >>= 8
+		}
+		out = append(out, 0x80|byte(len(lengthBytes)))
+		out = append(out, lengthBytes...)
+	}
+	return append(out, content...)
+}
+
+// readPacket 从连接中读取一个完整的 BER 节点
+func readPacket(r *bufio.Reader) (*packet, error) {
+	identifier, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		count := int(first & 0x7f)
+		if count == 0 || count > 4 {
+			return nil, errMalformed
+		}
+		length = 0
+		for i := 0; i < count; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, errMalformed
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return parsePacket(identifier, content)
+}
+
+// decode 从字节中解析一个 BER 节点，返回剩余的字节
+func decode(data []byte) (*packet, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errMalformed
+	}
+	identifier := data[0]
+	length := int(data[1])
+	offset := 2
+	if data[1]&0x80 != 0 {
+		count := int(data[1] & 0x7f)
+		if count == 0 || count > 4 || len(data) < 2+count {
+			return nil, nil, errMalformed
+		}
+		length = 0
+		for _, b := range data[2 : 2+count] {
+			length = length<<8 | int(b)
+		}
+		offset += count
+	}
+	if length < 0 || len(data)-offset < length {
+		return nil, nil, errMalformed
+	}
+	p, err := parsePacket(identifier, data[offset:offset+length])
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, data[offset+length:], nil
+}
+
+func parsePacket(identifier byte, content []byte) (*packet, error) {
+	if identifier&0x1f == 0x1f {
+		// 高位标签在 LDAP 中不会出现
+		return nil, errMalformed
+	}
+	p := &packet{Class: identifier & 0xc0, Constructed: identifier&0x20 != 0, Tag: identifier & 0x1f}
+	if !p.Constructed {
+		p.Value = content
+		return p, nil
+	}
+	for len(content) > 0 {
+		child, rest, err := decode(content)
+		if err != nil {
+			return nil, err
+		}
+		p.Children = append(p.Children, child)
+		content = rest
+	}
+	return p, nil
+}
+
+// int 解析 INTEGER 或 ENUMERATED 的值
+func (p *packet) int() int64 {
+	var value int64
+	for i, b := range p.Value {
+		if i == 0 && b&0x80 != 0 {
+			value = -1
+		}
+		value = value<<8 | int64(b)
+	}
+	return value
+}
+
+func (p *packet) child(index int) *packet {
+	if index < len(p.Children) {
+		return p.Children[index]
+	}
+	return &packet{}
+}
+
+// equal 等值过滤器 (attribute=value)
+func equal(attribute, value string) *packet {
+	return &packet{Class: classContext, Constructed: true, Tag: 3, Children: []*packet{newString(attribute), newString(value)}}
+}
+
+// present 存在过滤器 (attribute=*)
+func present(attribute string) *packet {
+	return &packet{Class: classContext, Tag: 7, Value: []byte(attribute)}
+}
+
+func TestEncodeKnownBytes(t *testing.T) {
+	tests := []struct {
+		packet *packet
+		want   string
+	}{
+		{newInteger(tagInteger, 3), "020103"},
+		{newInteger(tagInteger, 0), "020100"},
+		{newInteger(tagInteger, 127), "02017f"},
+		{newInteger(tagInteger, 128), "02020080"},
+		{newInteger(tagInteger, 256), "02020100"},
+		{newInteger(tagInteger, -1), "0201ff"},
+		{newInteger(tagInteger, -129), "0202ff7f"},
+		{newInteger(tagEnumerated, 2), "0a0102"},
+		{newBoolean(true), "0101ff"},
+		{newBoolean(false), "010100"},
+		{newString("cn"), "0402636e"},
+		{present("objectClass"), "870b6f626a656374436c617373"},
+		{equal("cn", "a"), "a3070402636e040161"},
+		// RFC 4511 绑定请求：消息ID 1，版本 3，DN cn=admin，简单认证密码 pw
+		{newSequence(newInteger(tagInteger, 1), &packet{Class: classApplication, Constructed: true, Tag: opBindRequest, Children: []*packet{
+			newInteger(tagInteger, 3),
+			newString("cn=admin"),
+			{Class: classContext, Tag: 0, Value: []byte("pw")},
+		}}), "301602010160110201030408636e3d61646d696e80027077"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.packet.encode()); got != tt.want {
+			t.Errorf("encode() = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestEncodeLongLength(t *testing.T) {
+	for _, n := range []int{127, 128, 255, 256, 70000} {
+		data := newString(strings.Repeat("x", n)).encode()
+		p, rest, err := decode(data)
+		if err != nil || len(rest) != 0 {
+			t.Fatalf("decode %d bytes: %v", n, err)
+		}
+		if len(p.Value) != n {
+			t.Errorf("decoded %d bytes, want %d", len(p.Value), n)
+		}
+	}
+	if got := hex.EncodeToString(newString(strings.Repeat("x", 200)).encode()[:3]); got != "0481c8" {
+		t.Errorf("length header = %s, want 0481c8", got)
+	}
+	if got := hex.EncodeToString(newString(strings.Repeat("x", 300)).encode()[:4]); got != "0482012c" {
+		t.Errorf("length header = %s, want 0482012c", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, 127, 128, -128, -129, 65535, 1 << 40, -(1 << 40)} {
+		p, _, err := decode(newInteger(tagInteger, value).encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.int() != value {
+			t.Errorf("int round trip %d = %d", value, p.int())
+		}
+	}
+	message := newSequence(
+		newInteger(tagInteger, 7),
+		&packet{Class: classApplication, Constructed: true, Tag: opSearchResultEntry, Children: []*packet{
+			newString("uid=alice,dc=example,dc=com"),
+			newSequence(newSequence(newString("mail"), &packet{Class: classUniversal, Constructed: true, Tag: tagSet, Children: []*packet{newString("alice@example.com")}})),
+		}},
+	)
+	p, err := readPacket(bufio.NewReader(bytes.NewReader(message.encode())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.child(0).int() != 7 || p.child(1).Class != classApplication || p.child(1).Tag != opSearchResultEntry {
+		t.Fatalf("unexpected packet %+v", p)
+	}
+	entry := p.child(1)
+	if string(entry.child(0).Value) != "uid=alice,dc=example,dc=com" {
+		t.Errorf("dn = %q", entry.child(0).Value)
+	}
+	if value := entry.child(1).child(0).child(1).child(0).Value; string(value) != "alice@example.com" {
+		t.Errorf("mail = %q", value)
+	}
+	// 越界的子节点返回空节点而不是 panic
+	if missing := p.child(5).child(2); missing.Value != nil || missing.Children != nil {
+		t.Error("missing child should be empty")
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"04",
+		"0405616263",
+		"0480",
+		"0485ffffffffff",
+		"1f0100",
+		"300304056162",
+	} {
+		data, _ := hex.DecodeString(input)
+		if _, _, err := decode(data); !errors.Is(err, errMalformed) {
+			t.Errorf("decode(%s) error = %v, want errMalformed", input, err)
+		}
+	}
+	// 超过 maxPacketSize 的长度在分配内存之前被拒绝
+	data, _ := hex.DecodeString("3084ffffffff")
+	if _, err := readPacket(bufio.NewReader(bytes.NewReader(data))); !errors.Is(err, errMalformed) {
+		t.Errorf("readPacket error = %v, want errMalformed", err)
+	}
+}
@@ -0,0 +1,284 @@
+package ldap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEntry 目录中的一个条目
+type fakeEntry struct {
+	password   string
+	attributes map[string][]string
+}
+
+// fakeDirectory 进程内的 LDAP 服务器，只实现简单绑定、base 与 subtree 搜索和解绑
+type fakeDirectory struct {
+	listener net.Listener
+	entries  map[string]fakeEntry
+
+	mu    sync.Mutex
+	binds []string
+}
+
+func newFakeDirectory(t *testing.T, entries map[string]fakeEntry) *fakeDirectory {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &fakeDirectory{listener: listener, entries: entries}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+func (d *fakeDirectory) url() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *fakeDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	bound := ""
+	for {
+		message, err := readPacket(reader)
+		if err != nil {
+			return
+		}
+		id := message.child(0).int()
+		op := message.child(1)
+		reply := func(op *packet) {
+			_, _ = conn.Write(newSequence(newInteger(tagInteger, id), op).encode())
+		}
+		switch op.Tag {
+		case opBindRequest:
+			dn, password := string(op.child(1).Value), string(op.child(2).Value)
+			d.mu.Lock()
+			d.binds = append(d.binds, dn)
+			d.mu.Unlock()
+			entry, ok := d.entries[dn]
+			if !ok || entry.password == "" || entry.password != password {
+				reply(ldapResult(opBindResponse, resultInvalidCredentials, "invalid credentials"))
+				continue
+			}
+			bound = dn
+			reply(ldapResult(opBindResponse, resultSuccess, ""))
+		case opSearchRequest:
+			if bound == "" {
+				reply(ldapResult(opSearchResultDone, 50, "insufficient access"))
+				continue
+			}
+			base, scope, filter := string(op.child(0).Value), op.child(1).int(), op.child(6)
+			var wanted []string
+			for _, attribute := range op.child(7).Children {
+				wanted = append(wanted, string(attribute.Value))
+			}
+			for dn, entry := range d.entries {
+				if scope == scopeBase && dn != base {
+					continue
+				}
+				if scope == scopeSubtree && !strings.HasSuffix(dn, ","+base) {
+					continue
+				}
+				if !matches(entry, filter) {
+					continue
+				}
+				reply(searchEntry(dn, entry, wanted))
+			}
+			// 引用不会被跟随，客户端应当忽略
+			reply(&packet{Class: classApplication, Constructed: true, Tag: opSearchResultRef, Children: []*packet{newString("ldap://other.example.com/")}})
+			reply(ldapResult(opSearchResultDone, resultSuccess, ""))
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func matches(entry fakeEntry, filter *packet) bool {
+	switch {
+	case filter.Class == classContext && filter.Tag == 7:
+		return true
+	case filter.Class == classContext && filter.Tag == 3:
+		attribute, value := strings.ToLower(string(filter.child(0).Value)), string(filter.child(1).Value)
+		for _, v := range entry.attributes[attribute] {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldapResult(tag byte, code int64, message string) *packet {
+	return &packet{Class: classApplication, Constructed: true, Tag: tag, Children: []*packet{
+		newInteger(tagEnumerated, code),
+		newString(""),
+		newString(message),
+	}}
+}
+
+func searchEntry(dn string, entry fakeEntry, wanted []string) *packet {
+	attributes := newSequence()
+	for _, name := range wanted {
+		values, ok := entry.attributes[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		set := &packet{Class: classUniversal, Constructed: true, Tag: tagSet}
+		for _, value := range values {
+			set.Children = append(set.Children, newString(value))
+		}
+		attributes.Children = append(attributes.Children, newSequence(newString(name), set))
+	}
+	return &packet{Class: classApplication, Constructed: true, Tag: opSearchResultEntry, Children: []*packet{newString(dn), attributes}}
+}
+
+func testDirectory(t *testing.T) *fakeDirectory {
+	return newFakeDirectory(t, map[string]fakeEntry{
+		"uid=alice,ou=people,dc=example,dc=com": {password: "alice-secret", attributes: map[string][]string{
+			"mail":        {"Alice@Example.com"},
+			"displayname": {"Alice"},
+			"memberof":    {"cn=admins,ou=groups,dc=example,dc=com"},
+		}},
+		"uid=bob,ou=people,dc=example,dc=com": {password: "bob-secret", attributes: map[string][]string{
+			"mail":        {"bob@example.com"},
+			"displayname": {"Bob"},
+		}},
+		`uid=a\,b,ou=people,dc=example,dc=com`: {password: "comma-secret", attributes: map[string][]string{
+			"mail": {"a,b@example.com"},
+		}},
+		"cn=ops,ou=groups,dc=example,dc=com": {attributes: map[string][]string{
+			"cn":     {"ops"},
+			"member": {"uid=bob,ou=people,dc=example,dc=com"},
+		}},
+		"cn=staff,ou=groups,dc=example,dc=com": {attributes: map[string][]string{
+			"cn":     {"staff"},
+			"member": {"uid=alice,ou=people,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"},
+		}},
+	})
+}
+
+func testConfig(d *fakeDirectory) *Config {
+	return &Config{
+		Url:             d.url(),
+		UserDnTemplate:  "uid={username},ou=people,dc=example,dc=com",
+		EmailAttr:       "mail",
+		NameAttr:        "displayName",
+		GroupMemberAttr: "member",
+		AdminGroups:     []string{"admins"},
+		Timeout:         5 * time.Second,
+	}
+}
+
+// TestAuthenticateRejectsEmptyPassword 空密码是匿名绑定，不能发送到服务器
+func TestAuthenticateRejectsEmptyPassword(t *testing.T) {
+	d := testDirectory(t)
+	if _, err := testConfig(d).Authenticate(context.Background(), "alice@example.com", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("error = %v, want ErrInvalidCredentials", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.binds) != 0 {
+		t.Error("an anonymous bind was sent to the server")
+	}
+}
+
+func TestAuthenticateMemberOf(t *testing.T) {
+	d := testDirectory(t)
+	user, err := testConfig(d).Authenticate(context.Background(), "alice@example.com", "alice-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.DN != "uid=alice,ou=people,dc=example,dc=com" || user.Email != "Alice@Example.com" || user.Name != "Alice" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if !user.Admin {
+		t.Error("memberOf cn=admins should make alice an admin")
+	}
+}
+
+func TestAuthenticateGroupSearch(t *testing.T) {
+	d := testDirectory(t)
+	config := testConfig(d)
+	config.GroupBaseDn = "ou=groups,dc=example,dc=com"
+	config.AdminGroups = []string{"cn=ops,ou=groups,dc=example,dc=com"}
+	bob, err := config.Authenticate(context.Background(), "bob@example.com", "bob-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bob.Groups) != 2 || !bob.Admin {
+		t.Errorf("unexpected groups for bob: %+v", bob)
+	}
+	// 配置了组搜索时忽略 memberOf
+	alice, err := config.Authenticate(context.Background(), "alice@example.com", "alice-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alice.Groups) != 1 || alice.Groups[0] != "cn=staff,ou=groups,dc=example,dc=com" || alice.Admin {
+		t.Errorf("unexpected groups for alice: %+v", alice)
+	}
+}
+
+func TestAuthenticateFailures(t *testing.T) {
+	d := testDirectory(t)
+	config := testConfig(d)
+	if _, err := config.Authenticate(context.Background(), "alice@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := config.Authenticate(context.Background(), "nobody@example.com", "secret"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown user error = %v, want ErrInvalidCredentials", err)
+	}
+	// 登录名中的逗号被转义，不能注入额外的 RDN
+	user, err := config.Authenticate(context.Background(), "a,b@example.com", "comma-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Email != "a,b@example.com" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last := d.binds[len(d.binds)-1]; last != `uid=a\,b,ou=people,dc=example,dc=com` {
+		t.Errorf("bind dn = %q", last)
+	}
+}
+
+func TestAuthenticateUnsupportedScheme(t *testing.T) {
+	config := &Config{Url: "http://127.0.0.1", UserDnTemplate: "uid={username}", Timeout: time.Second}
+	if _, err := config.Authenticate(context.Background(), "alice@example.com", "secret"); err == nil {
+		t.Error("http scheme should be rejected")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("LDAP_URL", "")
+	if LoadConfig() != nil {
+		t.Fatal("LoadConfig should return nil without LDAP_URL")
+	}
+	t.Setenv("LDAP_URL", "ldaps://ldap.example.com")
+	t.Setenv("LDAP_STARTTLS", "true")
+	t.Setenv("LDAP_USER_DN_TEMPLATE", "uid={username},dc=example,dc=com")
+	t.Setenv("LDAP_EMAIL_ATTR", "")
+	t.Setenv("LDAP_ADMIN_GROUPS", " admins ; ;cn=ops,dc=example,dc=com")
+	config := LoadConfig()
+	if config == nil || !config.StartTLS || config.EmailAttr != "mail" || config.NameAttr != "displayName" || config.GroupMemberAttr != "member" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+	if len(config.AdminGroups) != 2 || config.AdminGroups[0] != "admins" || config.AdminGroups[1] != "cn=ops,dc=example,dc=com" {
+		t.Errorf("admin groups = %q", config.AdminGroups)
+	}
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer 将邮件写入目录中的 .eml 文件而不真正发送，用于开发与测试
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	if dir == "" {
+		dir = "mails"
+	}
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(_ context.Context, to, subject, body string) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage("noreply@localhost", to, subject, body), 0o644)
+}
+
+// LogMailer 只把收件人与主题写入日志，未配置 MAIL_DRIVER 时使用
+// 邮件正文包含验证码与重置密码令牌，不能写入日志，需要查看正文时使用 file 驱动
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(_ context.Context, to, subject, _ string) error {
+	log.Logger.Warn().Str("to", to).Str("subject", subject).Msg("未配置 MAIL_DRIVER，邮件未发送")
+	return nil
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	_ "github.com/joho/godotenv/autoload"
+	"os"
+)
+
+// Mailer 邮件发送接口，业务代码只依赖该接口，具体驱动由 MAIL_DRIVER 决定
+type Mailer interface {
+	// Send 发送一封纯文本邮件
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailer 根据环境变量创建邮件发送器
+// MAIL_DRIVER 为 smtp 时通过 SMTP 服务器发送；为 file 时写入 MAIL_DIR 目录，便于在没有邮件服务器的环境中测试；
+// 其余情况不发送邮件，只在日志中记录收件人与主题
+func NewMailer() Mailer {
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		return NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	case "file":
+		return NewFileMailer(os.Getenv("MAIL_DIR"))
+	default:
+		return NewLogMailer()
+	}
+}
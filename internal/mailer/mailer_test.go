@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"io"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "file")
+	t.Setenv("MAIL_DIR", t.TempDir())
+	if _, ok := NewMailer().(*FileMailer); !ok {
+		t.Error("MAIL_DRIVER=file should create a FileMailer")
+	}
+	t.Setenv("MAIL_DRIVER", "smtp")
+	if _, ok := NewMailer().(*SMTPMailer); !ok {
+		t.Error("MAIL_DRIVER=smtp should create an SMTPMailer")
+	}
+	t.Setenv("MAIL_DRIVER", "")
+	if _, ok := NewMailer().(*LogMailer); !ok {
+		t.Error("unset MAIL_DRIVER should create a LogMailer")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	m := NewFileMailer(dir)
+	if err := m.Send(context.Background(), "alice@example.com", "验证码", "您的验证码是 123456\n五分钟内有效"); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*_alice_at_example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one mail file, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := parseMessage(t, string(data))
+	if got := msg.Header.Get("To"); got != "alice@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := decodeSubject(t, msg.Header.Get("Subject")); got != "验证码" {
+		t.Errorf("Subject = %q", got)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if string(body) != "您的验证码是 123456\r\n五分钟内有效" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+// TestLogMailer 日志中只能出现收件人与主题，不能出现包含验证码的正文
+func TestLogMailer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = logger }()
+	if err := NewLogMailer().Send(context.Background(), "alice@example.com", "subject", "您的验证码是 123456"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "alice@example.com") || strings.Contains(buf.String(), "123456") {
+		t.Errorf("unexpected log output %s", buf.String())
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg := parseMessage(t, string(buildMessage("noreply@example.com", "bob@example.com", "重置密码", "line1\nline2")))
+	if got := msg.Header.Get("From"); got != "noreply@example.com" {
+		t.Errorf("From = %q", got)
+	}
+	if got := msg.Header.Get("Content-Type"); got != "text/plain; charset=UTF-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+	if got := decodeSubject(t, msg.Header.Get("Subject")); got != "重置密码" {
+		t.Errorf("Subject = %q", got)
+	}
+}
+
+func parseMessage(t *testing.T, data string) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	return msg
+}
+
+func decodeSubject(t *testing.T, subject string) string {
+	t.Helper()
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil {
+		t.Fatalf("decode subject %q: %v", subject, err)
+	}
+	return decoded
+}
@@ -0,0 +1,151 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP 只实现发送一封邮件所需命令的 SMTP 服务器，记录收到的信封与邮件内容
+type fakeSMTP struct {
+	listener net.Listener
+	auth     chan string
+	from     chan string
+	rcpt     chan string
+	data     chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	s := &fakeSMTP{
+		listener: listener,
+		auth:     make(chan string, 1),
+		from:     make(chan string, 1),
+		rcpt:     make(chan string, 1),
+		data:     make(chan string, 1),
+	}
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			_ = text.PrintfLine("250-localhost")
+			_ = text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth <- arg
+			_ = text.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from <- arg
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			s.rcpt <- arg
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			s.data <- string(data)
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTP) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := newFakeSMTP(t)
+	m := NewSMTPMailer("127.0.0.1", server.port(), "user", "secret", "noreply@example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Send(ctx, "alice@example.com", "验证码", "您的验证码是 123456"); err != nil {
+		t.Fatal(err)
+	}
+	auth := <-server.auth
+	credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "PLAIN "))
+	if err != nil || string(credentials) != "\x00user\x00secret" {
+		t.Errorf("AUTH = %q", auth)
+	}
+	if from := <-server.from; from != "FROM:<noreply@example.com>" {
+		t.Errorf("MAIL %s", from)
+	}
+	if rcpt := <-server.rcpt; rcpt != "TO:<alice@example.com>" {
+		t.Errorf("RCPT %s", rcpt)
+	}
+	msg := parseMessage(t, <-server.data)
+	if got := decodeSubject(t, msg.Header.Get("Subject")); got != "验证码" {
+		t.Errorf("Subject = %q", got)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if strings.TrimRight(string(body), "\r\n") != "您的验证码是 123456" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSMTPMailerDefaultsFromToUsername(t *testing.T) {
+	if m := NewSMTPMailer("127.0.0.1", "25", "robot@example.com", "", ""); m.from != "robot@example.com" {
+		t.Errorf("from = %q", m.from)
+	}
+}
+
+// TestSMTPMailerContext 服务器没有响应时，Send 在上下文结束后返回
+func TestSMTPMailerContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		// 接受连接但不发送问候语，测试结束后再关闭
+		<-done
+		_ = conn.Close()
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	m := NewSMTPMailer("127.0.0.1", port, "", "", "noreply@example.com")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := m.Send(ctx, "alice@example.com", "subject", "body"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send error = %v, want context.DeadlineExceeded", err)
+	}
+}
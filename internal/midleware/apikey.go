@@ -0,0 +1,35 @@
+package midleware
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyMiddleware 返回一个使用 API 密钥认证机器人的中间件，需要注册在 JwtMiddleware 之前。
+// scopes 为允许使用 API 密钥访问的路由（gin 的路由模板）及其需要的权限范围，其余路由拒绝 API 密钥。
+// 请求没有携带 API 密钥时不做处理，交由 JwtMiddleware 校验访问令牌。
+func ApiKeyMiddleware(scopes map[string]string, loader func(c *gin.Context, key, scope string) (*types.Principal, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := token.ExtractApiKey(ctx)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		scope, ok := scopes[ctx.FullPath()]
+		if !ok {
+			_ = ctx.Error(exception.ErrPermissionDenied)
+			ctx.Abort()
+			return
+		}
+		principal, err := loader(ctx, key, scope)
+		if err != nil {
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		token.SetPrincipal(ctx, principal)
+		ctx.Next()
+	}
+}
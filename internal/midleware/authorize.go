@@ -0,0 +1,26 @@
+package midleware
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/token"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize 返回一个检查当前用户权限的中间件，需要拥有全部列出的权限才能继续访问。
+// 身份由 JwtMiddleware 加载，因此只能用在需要认证的路由上。
+func Authorize(permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := token.GetPrincipal(ctx)
+		if err != nil {
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		if !principal.Can(permissions...) {
+			_ = ctx.Error(exception.ErrPermissionDenied)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
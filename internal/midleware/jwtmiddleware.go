@@ -2,27 +2,44 @@ package midleware
 
 import (
 	"Gin-IM/pkg/token"
+	"Gin-IM/pkg/types"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
 // JwtMiddleware 返回一个基于JWT的认证中间件，它可以根据条件跳过认证过程。
 // skipper 是一个决定是否跳过JWT认证的函数。如果skipper为nil，或者skipper函数调用时返回false，则会进行JWT认证。
-func JwtMiddleware(skipper func(c *gin.Context) bool) gin.HandlerFunc {
+// loader 在令牌签名有效后检查其会话是否仍然存在并加载用户的权限，会话被注销后令牌立即失效。
+// 加载的身份保存在 gin 上下文中，处理函数与 Authorize 中间件通过 token.GetPrincipal 读取，不再重复校验。
+func JwtMiddleware(skipper func(c *gin.Context) bool, loader func(c *gin.Context, claims *types.GIClaims) (*types.Principal, error)) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 如果skipper不为空且skipper函数调用时返回true，则跳过JWT认证。
 		if skipper != nil && skipper(ctx) {
 			ctx.Next()
 			return
 		}
+		// 已由 ApiKeyMiddleware 通过 API 密钥认证。
+		if _, err := token.GetPrincipal(ctx); err == nil {
+			ctx.Next()
+			return
+		}
 
 		// 进行JWT认证，如果认证失败，则终止请求处理并返回错误信息。
-		if err := token.TokenValid(ctx); err != nil {
+		claims, err := token.ExtractClaims(ctx)
+		if err != nil {
 			_ = ctx.Error(err)
 			log.Logger.Error().Err(err).Msg("Jwt Error")
 			ctx.Abort()
 			return
 		}
+		// 检查令牌对应的会话并加载权限，已注销的会话无法继续访问。
+		principal, err := loader(ctx, claims)
+		if err != nil {
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return
+		}
+		token.SetPrincipal(ctx, principal)
 		// 认证成功，继续执行下一个中间件或处理函数。
 		ctx.Next()
 	}
@@ -2,6 +2,7 @@ package minio
 
 import (
 	"Gin-IM/pkg/defines"
+	"bytes"
 	"context"
 	"errors"
 	"github.com/minio/minio-go/v7"
@@ -172,3 +173,19 @@ func (s *MinIOStore) DeleteFiles(ctx context.Context, forceDelete bool, objectNa
 	// 所有对象删除成功，返回nil。
 	return nil
 }
+
+// PutFile 将内存中的数据直接上传为对象，适用于头像等经过服务端处理的小文件。
+//
+// ctx: 上下文，用于传递请求、超时等信息。
+// objectName: 对象名称。
+// data: 文件内容。
+// contentType: 文件的 MIME 类型。
+//
+// 返回错误，如果上传过程中遇到任何问题。
+func (s *MinIOStore) PutFile(ctx context.Context, objectName string, data []byte, contentType string) error {
+	if _, err := s.Client.PutObject(ctx, bucket, objectName, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		log.Logger.Error().Err(err).Msgf("failed to put %s/%s", bucket, objectName)
+		return err
+	}
+	return nil
+}
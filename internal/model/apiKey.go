@@ -0,0 +1,18 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ApiKey 机器人的 API 密钥，密钥明文只在创建与轮换时返回一次
+type ApiKey struct {
+	gorm.Model
+	BotId      string     `json:"botId" gorm:"column:botid;type:varchar(150);not null;index;comment:机器人用户ID"`
+	KeyId      string     `json:"keyId" gorm:"column:keyid;type:varchar(32);not null;uniqueIndex;comment:密钥ID，以明文包含在密钥中用于查找"`
+	KeyHash    string     `json:"-" gorm:"column:key_hash;type:char(64);not null;comment:密钥的SHA-256哈希"`
+	Name       string     `json:"name" gorm:"column:name;type:varchar(64);comment:密钥名称"`
+	Scopes     string     `json:"scopes" gorm:"column:scopes;type:varchar(255);not null;comment:逗号分隔的权限范围"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at;comment:最后使用时间"`
+	LastUsedIp string     `json:"lastUsedIp" gorm:"column:last_used_ip;type:varchar(64);comment:最后使用的IP"`
+}
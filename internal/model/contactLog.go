@@ -0,0 +1,11 @@
+package model
+
+import "gorm.io/gorm"
+
+type ContactLog struct {
+	gorm.Model
+	UserId   string `json:"userId" gorm:"column:userid;type:varchar(150);not null;uniqueIndex:idx_contact_version;comment:用户ID"`
+	FriendId string `json:"friendId" gorm:"column:friendid;type:varchar(150);not null;comment:好友ID"`
+	Version  int64  `json:"version" gorm:"column:version;not null;uniqueIndex:idx_contact_version;comment:通讯录版本"`
+	Op       int8   `json:"op" gorm:"column:op;type:tinyint;not null;comment:变更类型"`
+}
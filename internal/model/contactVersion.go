@@ -0,0 +1,10 @@
+package model
+
+import "gorm.io/gorm"
+
+// ContactVersion 用户当前的通讯录版本号，记录变更前锁定该行并加一，保证同一用户的版本号连续且不冲突
+type ContactVersion struct {
+	gorm.Model
+	UserId  string `json:"userId" gorm:"column:userid;type:varchar(150);not null;unique;comment:用户ID"`
+	Version int64  `json:"version" gorm:"column:version;not null;comment:通讯录版本"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "gorm.io/gorm"
+
+// LoginRecord 一次登录尝试的记录，邮箱不存在的尝试没有用户ID
+type LoginRecord struct {
+	gorm.Model
+	UserId     string `json:"userId" gorm:"column:userid;type:varchar(150);index;comment:用户ID"`
+	Email      string `json:"email" gorm:"type:varchar(255);index;comment:登录邮箱"`
+	Ip         string `json:"ip" gorm:"type:varchar(64);comment:IP地址"`
+	IpRange    string `json:"ipRange" gorm:"type:varchar(64);index;comment:IP所在网段"`
+	UserAgent  string `json:"userAgent" gorm:"type:varchar(255);comment:User-Agent"`
+	Device     string `json:"device" gorm:"type:varchar(64);comment:设备名称"`
+	Success    bool   `json:"success" gorm:"not null;default:false;comment:是否登录成功"`
+	Reason     string `json:"reason" gorm:"type:varchar(32);comment:失败原因"`
+	Suspicious bool   `json:"suspicious" gorm:"not null;default:false;comment:是否为新设备或新网段的登录"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type PurgeReport struct {
+	gorm.Model
+	UserId      string    `json:"userId" gorm:"column:userid;type:varchar(150);not null;index;comment:用户ID"`
+	Friendships int64     `json:"friendships" gorm:"column:friendships;not null;comment:删除的好友关系数量"`
+	ContactLogs int64     `json:"contactLogs" gorm:"column:contact_logs;not null;comment:删除的通讯录变更数量"`
+	Files       int64     `json:"files" gorm:"column:files;not null;comment:删除的文件记录数量"`
+	Objects     int64     `json:"objects" gorm:"column:objects;not null;comment:删除的存储对象数量"`
+	ValkeyKeys  int64     `json:"valkeyKeys" gorm:"column:valkey_keys;not null;comment:清理的缓存键数量"`
+	Errors      string    `json:"errors" gorm:"column:errors;type:text;comment:清理过程中的错误"`
+	PurgedAt    time.Time `json:"purgedAt" gorm:"column:purged_at;not null;comment:清理时间"`
+}
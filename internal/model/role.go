@@ -0,0 +1,25 @@
+package model
+
+import "gorm.io/gorm"
+
+// Role 角色，拥有一组权限
+type Role struct {
+	gorm.Model
+	Name        string       `json:"name" gorm:"column:name;type:varchar(64);not null;uniqueIndex;comment:角色名称"`
+	Description string       `json:"description" gorm:"column:description;type:varchar(255);comment:角色说明"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+}
+
+// Permission 权限，Code 与路由上声明的权限一致
+type Permission struct {
+	gorm.Model
+	Code        string `json:"code" gorm:"column:code;type:varchar(64);not null;uniqueIndex;comment:权限标识"`
+	Description string `json:"description" gorm:"column:description;type:varchar(255);comment:权限说明"`
+}
+
+// UserRole 用户与角色的关联，撤销时直接删除记录
+type UserRole struct {
+	gorm.Model
+	UserId string `json:"userId" gorm:"column:userid;type:varchar(150);not null;uniqueIndex:idx_user_role;comment:用户ID"`
+	RoleId uint   `json:"roleId" gorm:"column:role_id;not null;uniqueIndex:idx_user_role;index;comment:角色ID"`
+}
@@ -3,15 +3,24 @@ package model
 import (
 	"gorm.io/gorm"
 	"gorm.io/plugin/optimisticlock"
+	"time"
 )
 
 type User struct {
 	gorm.Model
-	Uuid     string `json:"uuid" gorm:"type:varchar(150);column:uuid;not null;unique;comment:uuid"`
-	Username string `json:"username" gorm:"type:varchar(32);column:username;unique;not null; comment:用户名"`
-	Password string `json:"password" gorm:"type:varchar(150);column:password;not null; comment:密码"`
-	Avatar   string `json:"avatar" gorm:"type:varchar(150);column:avatar;comment:头像"`
-	Email    string `json:"email" gorm:"type:varchar(80);unique;column:email;comment:邮箱"`
-	Status   int8   `json:"status" gorm:"type:tinyint;default:1;column:status;comment:状态"`
-	Version  optimisticlock.Version
+	Uuid          string     `json:"uuid" gorm:"type:varchar(150);column:uuid;not null;unique;comment:uuid"`
+	Username      string     `json:"username" gorm:"type:varchar(32);column:username;unique;not null; comment:用户名"`
+	Nickname      string     `json:"nickname" gorm:"type:varchar(32);column:nickname;index;comment:昵称"`
+	Password      string     `json:"-" gorm:"type:varchar(150);column:password;not null; comment:密码"`
+	Avatar        string     `json:"avatar" gorm:"type:varchar(150);column:avatar;comment:头像"`
+	Email         string     `json:"email" gorm:"type:varchar(80);unique;column:email;comment:邮箱"`
+	EmailHash     string     `json:"-" gorm:"type:char(64);column:email_hash;index;comment:加盐邮箱哈希"`
+	EmailVerified bool       `json:"emailVerified" gorm:"column:email_verified;default:true;comment:邮箱是否已验证"`
+	Status        int8       `json:"status" gorm:"type:tinyint;default:1;column:status;comment:状态"`
+	BanReason     string     `json:"banReason" gorm:"type:varchar(255);column:ban_reason;comment:封禁原因"`
+	BannedUntil   *time.Time `json:"bannedUntil" gorm:"column:banned_until;comment:封禁截止时间，为空表示永久封禁"`
+	LastSeen      *time.Time `json:"lastSeen" gorm:"column:last_seen;comment:最后在线时间"`
+	PurgeAt       *time.Time `json:"-" gorm:"column:purge_at;index;comment:注销后彻底清理数据的时间"`
+	BotOwner      string     `json:"botOwner,omitempty" gorm:"type:varchar(150);column:bot_owner;index;comment:机器人账号的创建者，为空表示普通用户"`
+	Version       optimisticlock.Version
 }
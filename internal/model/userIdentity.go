@@ -0,0 +1,11 @@
+package model
+
+import "gorm.io/gorm"
+
+type UserIdentity struct {
+	gorm.Model
+	UserId   string `json:"userId" gorm:"column:userid;type:varchar(150);not null;index;comment:用户ID"`
+	Provider string `json:"provider" gorm:"column:provider;type:varchar(32);not null;uniqueIndex:idx_identity;comment:身份提供方"`
+	Subject  string `json:"subject" gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_identity;comment:提供方的用户标识"`
+	Email    string `json:"email" gorm:"column:email;type:varchar(80);comment:提供方返回的邮箱"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/plugin/optimisticlock"
+)
+
+type UserPrivacy struct {
+	gorm.Model
+	UserId             string `json:"userId" gorm:"column:userid;type:varchar(150);not null;unique;comment:用户ID"`
+	SearchByEmail      bool   `json:"searchByEmail" gorm:"column:search_by_email;not null;comment:允许通过邮箱搜索"`
+	SearchByUsername   bool   `json:"searchByUsername" gorm:"column:search_by_username;not null;comment:允许通过用户名搜索"`
+	FriendPolicy       int8   `json:"friendPolicy" gorm:"column:friend_policy;type:tinyint;not null;comment:好友申请策略"`
+	Question           string `json:"question" gorm:"column:question;type:varchar(150);comment:好友验证问题"`
+	Answer             string `json:"-" gorm:"column:answer;type:varchar(150);comment:好友验证答案"`
+	LastSeenVisibility int8   `json:"lastSeenVisibility" gorm:"column:last_seen_visibility;type:tinyint;not null;comment:最后在线时间可见范围"`
+	Version            optimisticlock.Version
+}
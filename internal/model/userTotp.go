@@ -0,0 +1,21 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/plugin/optimisticlock"
+)
+
+type UserTotp struct {
+	gorm.Model
+	UserId   string `json:"userId" gorm:"column:userid;type:varchar(150);not null;unique;comment:用户ID"`
+	Secret   string `json:"-" gorm:"column:secret;type:varchar(64);not null;comment:TOTP密钥"`
+	Enabled  bool   `json:"enabled" gorm:"column:enabled;not null;comment:是否已启用"`
+	LastStep int64  `json:"-" gorm:"column:last_step;not null;comment:最后一次使用的时间步"`
+	Version  optimisticlock.Version
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserId   string `json:"userId" gorm:"column:userid;type:varchar(150);not null;index;comment:用户ID"`
+	CodeHash string `json:"-" gorm:"column:code_hash;type:char(64);not null;comment:恢复码哈希"`
+}
@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"Gin-IM/internal/mailer"
+	"context"
+	"fmt"
+)
+
+// MailNotifier 通过邮件发送登录提醒
+type MailNotifier struct {
+	mailer mailer.Mailer
+}
+
+func NewMailNotifier(m mailer.Mailer) *MailNotifier {
+	return &MailNotifier{mailer: m}
+}
+
+func (n *MailNotifier) NotifyLogin(ctx context.Context, alert LoginAlert) error {
+	if alert.Email == "" {
+		return nil
+	}
+	body := fmt.Sprintf("%s，你好：\n\n你的账号于 %s 在新的设备或网络登录。\n设备：%s\nIP：%s\nUser-Agent：%s\n\n如果这不是你本人的操作，请立即修改密码并在设备管理中注销该设备。",
+		alert.Username, alert.Time.Format("2006-01-02 15:04:05"), alert.Device, alert.Ip, alert.UserAgent)
+	return n.mailer.Send(ctx, alert.Email, "新设备登录提醒", body)
+}
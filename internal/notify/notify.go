@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"Gin-IM/internal/mailer"
+	"context"
+	"errors"
+	_ "github.com/joho/godotenv/autoload"
+	"os"
+	"strings"
+	"time"
+)
+
+// LoginAlert 来自新设备或新网段的登录，SessionId 为这次登录创建的会话
+type LoginAlert struct {
+	UserId    string    `json:"userId"`
+	Username  string    `json:"-"`
+	Email     string    `json:"-"`
+	SessionId string    `json:"sessionId"`
+	Ip        string    `json:"ip"`
+	Device    string    `json:"device"`
+	UserAgent string    `json:"userAgent"`
+	Time      time.Time `json:"time"`
+}
+
+// Notifier 登录提醒的发送接口，业务代码只依赖该接口，启用的渠道由 LOGIN_ALERT_CHANNELS 决定
+type Notifier interface {
+	NotifyLogin(ctx context.Context, alert LoginAlert) error
+}
+
+// PublishFunc 向频道发布一条消息
+type PublishFunc func(ctx context.Context, channel, message string) error
+
+// NewNotifier 根据环境变量创建登录提醒发送器
+// LOGIN_ALERT_CHANNELS 为逗号分隔的渠道列表，默认为 email,session：
+// email 发送邮件到用户邮箱；session 通过 Valkey 频道通知用户的其他在线会话；为 none 时不发送提醒
+func NewNotifier(m mailer.Mailer, publish PublishFunc) Notifier {
+	channels := os.Getenv("LOGIN_ALERT_CHANNELS")
+	if channels == "" {
+		channels = "email,session"
+	}
+	var notifiers Notifiers
+	for _, channel := range strings.Split(channels, ",") {
+		switch strings.TrimSpace(channel) {
+		case "email":
+			notifiers = append(notifiers, NewMailNotifier(m))
+		case "session":
+			notifiers = append(notifiers, NewSessionNotifier(publish))
+		}
+	}
+	return notifiers
+}
+
+// Notifiers 依次通过多个渠道发送，某个渠道失败不影响其他渠道
+type Notifiers []Notifier
+
+func (n Notifiers) NotifyLogin(ctx context.Context, alert LoginAlert) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.NotifyLogin(ctx, alert); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
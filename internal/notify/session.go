@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"Gin-IM/pkg/defines"
+	"context"
+	"encoding/json"
+)
+
+// SessionNotifier 在 LOGIN_ALERT 频道发布登录提醒，实时连接服务订阅后推送给该用户除 SessionId 以外的在线会话
+type SessionNotifier struct {
+	publish PublishFunc
+}
+
+func NewSessionNotifier(publish PublishFunc) *SessionNotifier {
+	return &SessionNotifier{publish: publish}
+}
+
+func (n *SessionNotifier) NotifyLogin(ctx context.Context, alert LoginAlert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return n.publish(ctx, defines.LOGIN_ALERT, string(data))
+}
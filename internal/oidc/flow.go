@@ -0,0 +1,131 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// IdClaims ID 令牌中使用到的声明
+type IdClaims struct {
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Nonce             string   `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+// flexBool 兼容部分提供方把 email_verified 以字符串 "true" 返回的情况
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	*b = flexBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// NewPKCE 生成 PKCE 的 code_verifier 与对应的 S256 code_challenge
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomString()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString 生成 32 字节随机数的 base64url 编码，用作 state、nonce 与 code_verifier
+func RandomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// AuthCodeUrl 生成授权码模式的授权地址
+func (p *Provider) AuthCodeUrl(ctx context.Context, state, nonce, challenge string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientId},
+		"redirect_uri":          {p.RedirectUrl},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.AuthUrl, "?") {
+		separator = "&"
+	}
+	return p.AuthUrl + separator + query.Encode(), nil
+}
+
+// Exchange 使用授权码与 code_verifier 换取令牌，并校验 ID 令牌的签名、签发方、受众、有效期与 nonce
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IdClaims, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectUrl},
+		"client_id":     {p.ClientId},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientId), url.QueryEscape(p.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, ErrExchange
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrExchange
+	}
+	var token struct {
+		IdToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, 1<<20)).Decode(&token); err != nil || token.IdToken == "" {
+		return nil, ErrExchange
+	}
+	return p.verifyIdToken(ctx, token.IdToken, nonce)
+}
+
+func (p *Provider) verifyIdToken(ctx context.Context, idToken, nonce string) (*IdClaims, error) {
+	parsed, err := jwt.ParseWithClaims(idToken, &IdClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid, token.Method.Alg())
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(p.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims, ok := parsed.Claims.(*IdClaims)
+	if !ok || !parsed.Valid || claims.Subject == "" || claims.Nonce != nonce {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
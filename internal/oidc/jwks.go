@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"time"
+)
+
+// keyRefreshInterval 遇到未知 kid 时重新获取公钥的最短间隔，避免伪造的 kid 导致频繁请求提供方
+const keyRefreshInterval = time.Minute
+
+type keyCache struct {
+	keys      map[string]publicKey
+	fetchedAt time.Time
+}
+
+type publicKey struct {
+	alg string
+	key interface{}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey 按 kid 查找提供方的验签公钥，未找到时在间隔允许的情况下重新获取一次（提供方可能已轮换密钥）
+func (p *Provider) publicKey(ctx context.Context, kid, alg string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil || (p.keys.find(kid) == nil && time.Since(p.keys.fetchedAt) > keyRefreshInterval) {
+		keys, err := p.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+	}
+	key := p.keys.find(kid)
+	if key == nil || (key.alg != "" && key.alg != alg) {
+		return nil, ErrInvalidToken
+	}
+	return key.key, nil
+}
+
+// find kid 为空且提供方只有一个密钥时使用该密钥
+func (c *keyCache) find(kid string) *publicKey {
+	if key, ok := c.keys[kid]; ok {
+		return &key
+	}
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return &key
+		}
+	}
+	return nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (*keyCache, error) {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.JwksUrl, &document); err != nil {
+		return nil, ErrDiscovery
+	}
+	cache := &keyCache{keys: make(map[string]publicKey), fetchedAt: time.Now()}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key := parseJWK(jwk); key != nil {
+			cache.keys[jwk.Kid] = publicKey{alg: jwk.Alg, key: key}
+		}
+	}
+	return cache, nil
+}
+
+// parseJWK 解析 RSA、EC（P-256/P-384）与 Ed25519 公钥，无法解析的密钥被忽略
+func parseJWK(jwk jsonWebKey) interface{} {
+	decode := func(value string) []byte {
+		data, _ := base64.RawURLEncoding.DecodeString(value)
+		return data
+	}
+	switch jwk.Kty {
+	case "RSA":
+		n, e := decode(jwk.N), decode(jwk.E)
+		if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil
+		}
+		x, y := decode(jwk.X), decode(jwk.Y)
+		if len(x) == 0 || len(y) == 0 {
+			return nil
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	case "OKP":
+		x := decode(jwk.X)
+		if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil
+		}
+		return ed25519.PublicKey(x)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	_ "github.com/joho/godotenv/autoload"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider 一个 OpenID Connect 身份提供方
+// 端点可以直接配置，也可以只配置 Issuer，首次使用时通过 /.well-known/openid-configuration 自动发现
+// Issuer 按配置原样与发现文档和 ID 令牌中的 iss 逐字比较，结尾的 / 不能省略或多加
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+	AuthUrl      string
+	TokenUrl     string
+	JwksUrl      string
+	// LinkByEmail 首次登录时按提供方确认过的邮箱关联已有账号，只应对可信的提供方开启
+	LinkByEmail bool
+
+	client *http.Client
+	mu     sync.Mutex
+	keys   *keyCache
+}
+
+var (
+	ErrDiscovery    = errors.New("oidc discovery failed")
+	ErrExchange     = errors.New("oidc code exchange failed")
+	ErrInvalidToken = errors.New("oidc id token invalid")
+)
+
+// LoadProviders 根据环境变量加载身份提供方
+// OIDC_PROVIDERS 为逗号分隔的名称列表，每个名称 NAME 对应以下配置：
+// OIDC_NAME_ISSUER、OIDC_NAME_CLIENT_ID、OIDC_NAME_CLIENT_SECRET、OIDC_NAME_REDIRECT_URL、
+// OIDC_NAME_SCOPES（空格分隔，默认 openid email profile），
+// 以及可选的 OIDC_NAME_AUTH_URL、OIDC_NAME_TOKEN_URL、OIDC_NAME_JWKS_URL，
+// OIDC_NAME_LINK_BY_EMAIL=true 时允许按邮箱关联已有账号
+func LoadProviders() map[string]*Provider {
+	providers := make(map[string]*Provider)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		scopes := strings.Fields(os.Getenv(prefix + "SCOPES"))
+		if len(scopes) == 0 {
+			scopes = []string{"openid", "email", "profile"}
+		}
+		providers[strings.ToLower(name)] = &Provider{
+			Name:         strings.ToLower(name),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectUrl:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+			AuthUrl:      os.Getenv(prefix + "AUTH_URL"),
+			TokenUrl:     os.Getenv(prefix + "TOKEN_URL"),
+			JwksUrl:      os.Getenv(prefix + "JWKS_URL"),
+			LinkByEmail:  os.Getenv(prefix+"LINK_BY_EMAIL") == "true",
+			client:       &http.Client{Timeout: 10 * time.Second},
+		}
+	}
+	return providers
+}
+
+// discover 补全未配置的端点，只在第一次成功时请求发现文档
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.AuthUrl != "" && p.TokenUrl != "" && p.JwksUrl != "" {
+		return nil
+	}
+	var document struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JwksUri               string `json:"jwks_uri"`
+	}
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", &document); err != nil {
+		return ErrDiscovery
+	}
+	if document.Issuer != p.Issuer {
+		return ErrDiscovery
+	}
+	if p.AuthUrl == "" {
+		p.AuthUrl = document.AuthorizationEndpoint
+	}
+	if p.TokenUrl == "" {
+		p.TokenUrl = document.TokenEndpoint
+	}
+	if p.JwksUrl == "" {
+		p.JwksUrl = document.JwksUri
+	}
+	if p.AuthUrl == "" || p.TokenUrl == "" || p.JwksUrl == "" {
+		return ErrDiscovery
+	}
+	return nil
+}
+
+// getJSON 请求地址并解析 JSON 响应
+func (p *Provider) getJSON(ctx context.Context, url string, value interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected status " + resp.Status)
+	}
+	return json.NewDecoder(http.MaxBytesReader(nil, resp.Body, 1<<20)).Decode(value)
+}
@@ -0,0 +1,368 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockProvider 本地的 OpenID Connect 提供方，支持发现文档、令牌端点与 JWKS
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	// issuer 发现文档与 ID 令牌中的 iss，默认为服务地址
+	issuer string
+
+	mu sync.Mutex
+	// codes 授权码对应的 code_challenge 与 nonce，模拟用户在授权页面同意授权
+	codes map[string][2]string
+	// claims 修改签发的 ID 令牌，用于构造各种无效的令牌
+	claims     func(claims jwt.MapClaims)
+	jwksHits   int
+	tokenError bool
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{key: key, kid: "key-1", codes: make(map[string][2]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 m.issuer,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.jwksHits++
+		kid := m.kid
+		m.mu.Unlock()
+		writeJSON(w, map[string]interface{}{"keys": []jsonWebKey{
+			{Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256", N: b64(m.key.N.Bytes()), E: b64(big.NewInt(int64(m.key.E)).Bytes())},
+			// 用于加密的密钥不能用来验签
+			{Kty: "RSA", Kid: "enc-key", Use: "enc", N: b64(m.key.N.Bytes()), E: b64(big.NewInt(int64(m.key.E)).Bytes())},
+		}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	m.issuer = m.server.URL
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockProvider) provider() *Provider {
+	return &Provider{
+		Name:         "mock",
+		Issuer:       m.issuer,
+		ClientId:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectUrl:  "https://im.example.com/callback",
+		Scopes:       []string{"openid", "email"},
+		client:       m.server.Client(),
+	}
+}
+
+func (m *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tokenError {
+		http.Error(w, `{"error":"server_error"}`, http.StatusInternalServerError)
+		return
+	}
+	if id, secret, ok := r.BasicAuth(); !ok || id != "client-id" || secret != "client-secret" {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("redirect_uri") != "https://im.example.com/callback" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant[0] {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	delete(m.codes, r.PostForm.Get("code"))
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.issuer,
+		"aud":            "client-id",
+		"sub":            "subject-1",
+		"email":          "alice@example.com",
+		"email_verified": "true",
+		"name":           "Alice",
+		"nonce":          grant[1],
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+	}
+	if m.claims != nil {
+		m.claims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = m.kid
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+}
+
+// authorize 按授权地址中的参数登记授权码，相当于用户在提供方完成了登录
+func (m *mockProvider) authorize(t *testing.T, authUrl, code string) {
+	t.Helper()
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if parsed.Path != "/authorize" || query.Get("client_id") != "client-id" || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization url %s", authUrl)
+	}
+	m.mu.Lock()
+	m.codes[code] = [2]string{query.Get("code_challenge"), query.Get("nonce")}
+	m.mu.Unlock()
+}
+
+// login 完成一次授权码登录，返回 Exchange 的结果
+func (m *mockProvider) login(t *testing.T, p *Provider, nonce string) (*IdClaims, error) {
+	t.Helper()
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authUrl, err := p.AuthCodeUrl(context.Background(), "state", nonce, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.authorize(t, authUrl, "code-1")
+	return p.Exchange(context.Background(), "code-1", verifier, nonce)
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	claims, err := m.login(t, p, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "alice@example.com" || !bool(claims.EmailVerified) || claims.Name != "Alice" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if p.TokenUrl != m.server.URL+"/token" || p.JwksUrl != m.server.URL+"/jwks" {
+		t.Errorf("endpoints were not discovered: %s %s", p.TokenUrl, p.JwksUrl)
+	}
+	// 授权码只能使用一次
+	if _, err := p.Exchange(context.Background(), "code-1", "verifier", "nonce-1"); !errors.Is(err, ErrExchange) {
+		t.Errorf("reused code error = %v, want ErrExchange", err)
+	}
+}
+
+func TestExchangeRejectsInvalidIdToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(claims jwt.MapClaims)
+	}{
+		{"issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"audience", func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"issued in future", func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"nonce", func(c jwt.MapClaims) { c["nonce"] = "other" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.claims = tt.claims
+			if _, err := m.login(t, m.provider(), "nonce-1"); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	_, challenge, _ := NewPKCE()
+	authUrl, err := p.AuthCodeUrl(context.Background(), "state", "nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.authorize(t, authUrl, "code-1")
+	if _, err := p.Exchange(context.Background(), "code-1", "wrong-verifier", "nonce"); !errors.Is(err, ErrExchange) {
+		t.Errorf("error = %v, want ErrExchange", err)
+	}
+	m.tokenError = true
+	if _, err := p.Exchange(context.Background(), "code-1", "wrong-verifier", "nonce"); !errors.Is(err, ErrExchange) {
+		t.Errorf("error = %v, want ErrExchange", err)
+	}
+}
+
+// TestKeyRotation 提供方轮换密钥后，未知的 kid 会触发重新获取 JWKS，但不会早于 keyRefreshInterval
+func TestKeyRotation(t *testing.T) {
+	m := newMockProvider(t)
+	p := m.provider()
+	if _, err := m.login(t, p, "nonce-1"); err != nil {
+		t.Fatal(err)
+	}
+	m.mu.Lock()
+	m.kid = "key-2"
+	m.mu.Unlock()
+	if _, err := m.login(t, p, "nonce-2"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown kid within refresh interval: error = %v, want ErrInvalidToken", err)
+	}
+	p.keys.fetchedAt = time.Now().Add(-2 * keyRefreshInterval)
+	if _, err := m.login(t, p, "nonce-3"); err != nil {
+		t.Errorf("rotated key: %v", err)
+	}
+	if m.jwksHits != 2 {
+		t.Errorf("jwks fetched %d times, want 2", m.jwksHits)
+	}
+}
+
+// TestExchangeIssuerWithTrailingSlash 部分提供方的 issuer 以 / 结尾，配置、发现文档与 iss 必须完全一致
+func TestExchangeIssuerWithTrailingSlash(t *testing.T) {
+	m := newMockProvider(t)
+	m.issuer = m.server.URL + "/"
+	if _, err := m.login(t, m.provider(), "nonce-1"); err != nil {
+		t.Fatal(err)
+	}
+	m = newMockProvider(t)
+	m.issuer = m.server.URL + "/"
+	m.claims = func(c jwt.MapClaims) { c["iss"] = m.server.URL }
+	if _, err := m.login(t, m.provider(), "nonce-1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("issuer without trailing slash: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	for _, issuer := range []string{m.server.URL + "/other", m.server.URL + "/"} {
+		p := m.provider()
+		p.Issuer = issuer
+		if _, err := p.AuthCodeUrl(context.Background(), "state", "nonce", "challenge"); !errors.Is(err, ErrDiscovery) {
+			t.Errorf("issuer %s: error = %v, want ErrDiscovery", issuer, err)
+		}
+	}
+}
+
+func TestAuthCodeUrlWithConfiguredEndpoints(t *testing.T) {
+	p := &Provider{
+		ClientId:    "client-id",
+		RedirectUrl: "https://im.example.com/callback",
+		Scopes:      []string{"openid", "email"},
+		AuthUrl:     "https://idp.example.com/authorize?prompt=login",
+		TokenUrl:    "https://idp.example.com/token",
+		JwksUrl:     "https://idp.example.com/jwks",
+	}
+	authUrl, err := p.AuthCodeUrl(context.Background(), "state-1", "nonce-1", "challenge-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("prompt") != "login" || query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" ||
+		query.Get("scope") != "openid email" || query.Get("redirect_uri") != "https://im.example.com/callback" {
+		t.Errorf("unexpected authorization url %s", authUrl)
+	}
+}
+
+func TestParseJWK(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := parseJWK(jsonWebKey{Kty: "RSA", N: b64(rsaKey.N.Bytes()), E: b64(big.NewInt(int64(rsaKey.E)).Bytes())})
+	if key, ok := parsed.(*rsa.PublicKey); !ok || !key.Equal(&rsaKey.PublicKey) {
+		t.Error("RSA key was not parsed")
+	}
+	parsed = parseJWK(jsonWebKey{Kty: "EC", Crv: "P-256", X: b64(ecKey.X.Bytes()), Y: b64(ecKey.Y.Bytes())})
+	if key, ok := parsed.(*ecdsa.PublicKey); !ok || !key.Equal(&ecKey.PublicKey) {
+		t.Error("EC key was not parsed")
+	}
+	parsed = parseJWK(jsonWebKey{Kty: "OKP", Crv: "Ed25519", X: b64(edKey)})
+	if key, ok := parsed.(ed25519.PublicKey); !ok || !key.Equal(edKey) {
+		t.Error("Ed25519 key was not parsed")
+	}
+	for _, jwk := range []jsonWebKey{
+		{Kty: "RSA", N: b64(rsaKey.N.Bytes())},
+		{Kty: "RSA", N: b64(rsaKey.N.Bytes()), E: b64(make([]byte, 5))},
+		{Kty: "EC", Crv: "P-521", X: b64(ecKey.X.Bytes()), Y: b64(ecKey.Y.Bytes())},
+		{Kty: "OKP", Crv: "Ed25519", X: b64(edKey[:16])},
+		{Kty: "oct"},
+	} {
+		if key := parseJWK(jwk); key != nil {
+			t.Errorf("parseJWK(%+v) = %v, want nil", jwk, key)
+		}
+	}
+}
+
+func TestLoadProviders(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", " Google , ,corp")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com/")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "google-client")
+	t.Setenv("OIDC_CORP_SCOPES", "openid groups")
+	t.Setenv("OIDC_CORP_LINK_BY_EMAIL", "true")
+	providers := LoadProviders()
+	if len(providers) != 2 {
+		t.Fatalf("loaded %d providers, want 2", len(providers))
+	}
+	google := providers["google"]
+	if google == nil || google.Name != "google" || google.Issuer != "https://accounts.google.com/" || google.ClientId != "google-client" {
+		t.Errorf("unexpected google provider %+v", google)
+	}
+	if strings.Join(google.Scopes, " ") != "openid email profile" || google.LinkByEmail {
+		t.Errorf("unexpected google defaults %+v", google)
+	}
+	corp := providers["corp"]
+	if corp == nil || strings.Join(corp.Scopes, " ") != "openid groups" || !corp.LinkByEmail {
+		t.Errorf("unexpected corp provider %+v", corp)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
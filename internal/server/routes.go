@@ -3,6 +3,7 @@ package server
 import (
 	_ "Gin-IM/docs"
 	"Gin-IM/internal/midleware"
+	"Gin-IM/pkg/enums"
 	"net/http"
 	"strings"
 
@@ -28,25 +29,46 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// Error Middleware
 	r.Use(midleware.ErrorHandler())
 
+	// 允许机器人通过 API 密钥访问的路由及需要的权限范围，其余路由只接受用户的访问令牌
+	botScopes := map[string]string{
+		"/api/file/upload":    enums.SCOPE_UPLOAD_FILE,
+		"/api/file/pushparts": enums.SCOPE_UPLOAD_FILE,
+		"/api/file/merge":     enums.SCOPE_UPLOAD_FILE,
+	}
+
+	// API Key Middleware
+	r.Use(midleware.ApiKeyMiddleware(botScopes, s.LoadApiKeyPrincipal))
+
 	//JWT Middleware
 	r.Use(midleware.JwtMiddleware(func(ctx *gin.Context) bool {
 		return strings.Contains(ctx.Request.URL.Path, "/api/account/login") ||
 			strings.Contains(ctx.Request.URL.Path, "/api/account/register") ||
 			strings.Contains(ctx.Request.URL.Path, "/api/account/getcaptcha") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/refresh") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/verifyemail") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/resendverify") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/forgotpassword") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/resetpassword") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/2fa/verify") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/account/restore") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/avatar/") ||
+			strings.Contains(ctx.Request.URL.Path, "/.well-known/") ||
+			strings.Contains(ctx.Request.URL.Path, "/api/oauth/") ||
 			strings.Contains(ctx.Request.URL.Path, "/swagger/")
-	}))
+	}, s.LoadPrincipal))
 
 	// CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Add your frontend URL
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-Api-Key"},
 		AllowCredentials: true, // Enable cookies/auth
 	}))
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	r.GET("/health", s.HealthHandler)
+	r.GET("/.well-known/jwks.json", s.GetJWKS)
 	api := r.Group("/api")
 	{
 		account := api.Group("/account")
@@ -54,10 +76,32 @@ func (s *Server) RegisterRoutes() http.Handler {
 			account.GET("/getcaptcha", s.GetCaptcha)
 			account.POST("/register", s.Register)
 			account.POST("/login", s.Login)
+			account.POST("/refresh", s.RefreshToken)
+			account.POST("/verifyemail", s.VerifyEmail)
+			account.POST("/resendverify", s.ResendVerifyEmail)
+			account.POST("/forgotpassword", s.ForgotPassword)
+			account.POST("/resetpassword", s.ResetPassword)
 			account.GET("/getuserinfo", s.GetUserInfo)
 			account.GET("/logout", s.Logout)
 			account.POST("/search", s.Search)
+			account.GET("/privacy", s.GetPrivacy)
+			account.POST("/privacy", s.UpdatePrivacy)
+			account.GET("/sessions", s.ListSessions)
+			account.POST("/sessions/revoke", s.RevokeSession)
+			account.POST("/sessions/revokeothers", s.RevokeOtherSessions)
+			account.POST("/signins", s.ListLoginHistory)
+			account.POST("/profile", s.UpdateProfile)
+			account.POST("/password", s.ChangePassword)
+			account.POST("/avatar", s.UploadAvatar)
+			account.POST("/2fa/setup", s.SetupTwoFactor)
+			account.POST("/2fa/enable", s.EnableTwoFactor)
+			account.POST("/2fa/disable", s.DisableTwoFactor)
+			account.POST("/2fa/recovery", s.RegenerateRecoveryCodes)
+			account.POST("/2fa/verify", s.VerifyTwoFactorLogin)
+			account.POST("/delete", s.DeleteAccount)
+			account.POST("/restore", s.RestoreAccount)
 		}
+		api.GET("/avatar/*name", s.GetAvatar)
 		friend := api.Group("/friend")
 		{
 			friend.POST("/add", s.AddFriend)
@@ -67,6 +111,40 @@ func (s *Server) RegisterRoutes() http.Handler {
 			friend.POST("/cancelblack", s.CancelBlack)
 			friend.POST("/delete", s.DeleteFriend)
 			friend.POST("/agree", s.AgreeFriendRequest)
+			friend.GET("/suggest", s.GetFriendSuggestions)
+			friend.POST("/sync", s.SyncFriendList)
+			friend.POST("/question", s.GetFriendQuestion)
+			friend.GET("/discover/salt", s.GetDiscoverySalt)
+			friend.POST("/discover", s.DiscoverContacts)
+		}
+		invite := api.Group("/invite")
+		{
+			invite.POST("/create", s.CreateInvite)
+			invite.POST("/redeem", s.RedeemInvite)
+		}
+		oauth := api.Group("/oauth")
+		{
+			oauth.GET("/:provider/authorize", s.OAuthAuthorize)
+			oauth.POST("/callback", s.OAuthCallback)
+		}
+		admin := api.Group("/admin")
+		{
+			admin.POST("/unlock", midleware.Authorize(enums.PERM_LOGIN_UNLOCK), s.UnlockLogin)
+			admin.POST("/ban", midleware.Authorize(enums.PERM_USER_BAN), s.BanUser)
+			admin.POST("/unban", midleware.Authorize(enums.PERM_USER_BAN), s.UnbanUser)
+			admin.GET("/roles", midleware.Authorize(enums.PERM_ROLE_MANAGE), s.ListRoles)
+			admin.POST("/role", midleware.Authorize(enums.PERM_ROLE_MANAGE), s.SaveRole)
+			admin.POST("/role/assign", midleware.Authorize(enums.PERM_ROLE_MANAGE), s.AssignRole)
+			admin.POST("/role/revoke", midleware.Authorize(enums.PERM_ROLE_MANAGE), s.RevokeRole)
+			admin.POST("/signins", midleware.Authorize(enums.PERM_LOGIN_HISTORY), s.AdminLoginHistory)
+		}
+		bot := api.Group("/bot", midleware.Authorize(enums.PERM_BOT_MANAGE))
+		{
+			bot.POST("/create", s.CreateBot)
+			bot.GET("/list", s.ListBots)
+			bot.POST("/key/create", s.CreateApiKey)
+			bot.POST("/key/rotate", s.RotateApiKey)
+			bot.POST("/key/revoke", s.RevokeApiKey)
 		}
 		file := api.Group("/file")
 		{
@@ -74,6 +152,7 @@ func (s *Server) RegisterRoutes() http.Handler {
 			file.POST("/download", s.GetShortUrl)
 			file.POST("/delete", s.DeleteFile)
 			file.POST("/merge", s.MergeFile)
+			file.POST("/list", s.ListFiles)
 			file.GET("/trash", s.GetTrash)
 			file.POST("/recovery", s.RecoveryFile)
 			file.POST("/pushparts", s.PushPartsInfo)
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"Gin-IM/pkg/defines"
+	"context"
+	"time"
+)
+
+// startSchedules 启动后台定时任务
+func (s *Server) startSchedules() {
+	// 启动时为历史用户补充邮箱哈希，盐值变化时重新计算，只需执行一次
+	go s.BackfillEmailHash(context.Background())
+	go s.BackfillFileMetadata(context.Background())
+	go s.InitRoles(context.Background())
+	go runEvery(defines.FRIEND_SUGGEST_REFRESH*time.Second, s.RefreshFriendSuggestions)
+	go runEvery(defines.ACCOUNT_PURGE_INTERVAL*time.Second, s.PurgeDeletedAccounts)
+	go runEvery(defines.LOGIN_HISTORY_PURGE*time.Second, s.PurgeLoginHistory)
+}
+
+// runEvery 以固定间隔执行任务，启动时会先执行一次
+func runEvery(interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job(context.Background())
+		<-ticker.C
+	}
+}
@@ -3,6 +3,8 @@ package server
 import (
 	"Gin-IM/internal/handler"
 	"Gin-IM/internal/model"
+	"Gin-IM/pkg/utils"
+	"Gin-IM/pkg/validates"
 	"fmt"
 	"net/http"
 	"os"
@@ -21,15 +23,24 @@ type Server struct {
 }
 
 func NewServer() *http.Server {
+	// 没有盐值时邮箱哈希可以被直接枚举，通讯录发现接口在这种情况下不可用
+	if utils.DiscoverySalt() == "" {
+		log.Logger.Warn().Msg("未配置 CONTACT_DISCOVERY_SALT，通讯录发现已停用")
+	}
+	// 配置了泄露密码文件却无法加载时不能静默跳过检查
+	if err := validates.LoadBreached(os.Getenv("BREACHED_PASSWORD_FILE")); err != nil {
+		log.Logger.Fatal().Err(err).Msg("加载泄露密码文件失败")
+	}
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
 		port: port,
 
 		Handlers: handler.NewHandler(),
 	}
-	if err := NewServer.InitDBTables(&model.User{}, &model.UserFriend{},&model.File{}); err != nil {
+	if err := NewServer.InitDBTables(&model.User{}, &model.UserFriend{},&model.File{}, &model.ContactLog{}, &model.ContactVersion{}, &model.UserPrivacy{}, &model.UserTotp{}, &model.RecoveryCode{}, &model.PurgeReport{}, &model.UserIdentity{}, &model.Role{}, &model.Permission{}, &model.UserRole{}, &model.ApiKey{}, &model.LoginRecord{}); err != nil {
 		log.Logger.Fatal().Err(err).Msg("Failed to initialize database tables")
 	}
+	NewServer.startSchedules()
 	// Declare Server config
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
@@ -1,20 +1,94 @@
 package defines
 
 const (
-	Timeout              = 500
-	PASSWORD_REGEX       = `^(?=.*[a-z])(?=.*[A-Z])(?=.*\d)[A-Za-z\d]{8,32}$`
-	FIELD_ERROR_INFO     = "field_error_info"
-	CAPTCHA              = "captcha:"
-	CAPTCHA_TIMEOUT      = 5 * 60
-	TOKEN_EXPIRE         = 24
-	USER_TOKEN_KEY       = "user_token:"
-	USER_TOKEN           = 60 * 60 * 24
-	MESSAGE_SEND_TIMEOUT = 5
-	FILE_SHORT_SIGN      = 24
-	DEFAUT_BUCKETNAME    = "default"
-	CHUNK_NUM            = 30
-	UPLOAD_ID            = "uploadId:"
-	SINGLE_UPLOAD_ID     = "Single"
-	MIN_CHUNK_SIZE       = 5 * 1024 * 1024
-	COMPLETED_PARTS      = "completedParts:"
+	Timeout                = 500
+	FIELD_ERROR_INFO       = "field_error_info"
+	CAPTCHA                = "captcha:"
+	CAPTCHA_TIMEOUT        = 5 * 60
+	TRUSTED_DEVICE         = "trustedDevice:"
+	TRUSTED_DEVICE_TTL     = 60 * 60 * 24 * 30
+	TOKEN_EXPIRE           = 15
+	USER_TOKEN_KEY         = "user_token:"
+	USER_TOKEN             = 60 * 60 * 24 * 30
+	USER_SESSIONS          = "user_sessions:"
+	REFRESH_USED           = "refresh_used:"
+	SESSION_SEEN           = "session_seen:"
+	SESSION_REVOKED        = "session_revoked"
+	LOGIN_ALERT            = "login_alert"
+	EMAIL_VERIFY           = "emailVerify:"
+	EMAIL_VERIFY_FAIL      = "emailVerifyFail:"
+	EMAIL_VERIFY_COOLDOWN  = "emailVerifyCooldown:"
+	EMAIL_VERIFY_TIMEOUT   = 30 * 60
+	EMAIL_RESEND_INTERVAL  = 60
+	EMAIL_VERIFY_ATTEMPTS  = 5
+	MAIL_SEND_TIMEOUT      = 30
+	PASSWORD_RESET         = "passwordReset:"
+	PASSWORD_RESET_TIMEOUT = 30 * 60
+	PASSWORD_RESET_SENT    = "passwordResetSent:"
+	AVATAR_PREFIX          = "avatar/"
+	AVATAR_SIZE            = 256
+	AVATAR_MAX_PIXELS      = 4096 * 4096
+	AVATAR_MAX_SIZE        = 5 * 1024 * 1024
+	TOTP_ISSUER            = "Gin-IM"
+	RECOVERY_CODE_COUNT    = 10
+	LOGIN_CHALLENGE        = "loginChallenge:"
+	LOGIN_CHALLENGE_FAIL   = "loginChallengeFail:"
+	LOGIN_CHALLENGE_TTL    = 5 * 60
+	LOGIN_CHALLENGE_TRIES  = 5
+	LOGIN_FAIL             = "loginFail:"
+	LOGIN_LOCK             = "loginLock:"
+	LOGIN_LOCK_LEVEL       = "loginLockLevel:"
+	LOGIN_LOCK_SEEN        = "loginLockSeen:"
+	LOGIN_FAIL_WINDOW      = 15 * 60
+	LOGIN_ACCOUNT_ATTEMPTS = 5
+	LOGIN_IP_ATTEMPTS      = 20
+	LOGIN_LOCK_BASE        = 60
+	LOGIN_LOCK_MAX         = 60 * 60 * 24
+	USER_BANNED            = "userBanned:"
+	ACCOUNT_DELETE_GRACE   = 60 * 60 * 24 * 30
+	ACCOUNT_PURGE_INTERVAL = 60 * 60
+	ACCOUNT_PURGE_BATCH    = 100
+	OIDC_STATE             = "oidcState:"
+	OIDC_STATE_TTL         = 10 * 60
+	LDAP_PROVIDER          = "ldap"
+	USER_PERMISSIONS       = "userPermissions:"
+	USER_PERMISSIONS_TTL   = 5 * 60
+	API_KEY_PREFIX         = "gim_"
+	API_KEY_RATE           = "apiKeyRate:"
+	API_KEY_RATE_WINDOW    = 60
+	API_KEY_RATE_LIMIT     = 120
+	API_KEY_FAIL           = "apiKeyFail:"
+	API_KEY_FAIL_LIMIT     = 20
+	API_KEY_TOUCH_INTERVAL = 60
+	API_KEY_PER_BOT        = 10
+	BOT_EMAIL_DOMAIN       = "@bot.invalid"
+	MESSAGE_SEND_TIMEOUT   = 5
+	FILE_SHORT_SIGN        = 24
+	DEFAUT_BUCKETNAME      = "default"
+	CHUNK_NUM              = 30
+	UPLOAD_ID              = "uploadId:"
+	SINGLE_UPLOAD_ID       = "Single"
+	MIN_CHUNK_SIZE         = 5 * 1024 * 1024
+	COMPLETED_PARTS        = "completedParts:"
+	FILE_LIST_SIZE         = 20
+	FILE_METADATA_BATCH    = 500
+	FRIEND_SUGGEST         = "friendSuggest:"
+	FRIEND_SUGGEST_TIMEOUT = 60 * 60 * 2
+	FRIEND_SUGGEST_REFRESH = 60 * 60
+	FRIEND_SUGGEST_LIMIT   = 20
+	FRIEND_SUGGEST_BATCH   = 100
+	SEARCH_PAGE_SIZE       = 20
+	LOGIN_HISTORY_SIZE     = 20
+	LOGIN_HISTORY_DAYS     = 90
+	LOGIN_HISTORY_BATCH    = 1000
+	LOGIN_HISTORY_PURGE    = 60 * 60 * 24
+	INVITE_TOKEN_EXPIRE    = 24 * 7
+	INVITE_AUDIENCE        = "invite"
+	TOKEN_ISSUER           = "Gin-IM"
+	ACCESS_AUDIENCE        = "access"
+	QR_CODE_SCALE          = 8
+	CONTACT_DISCOVER       = "contactDiscover:"
+	CONTACT_DISCOVER_LIMIT = 2000
+	CONTACT_DISCOVER_TTL   = 60 * 60 * 24
+	EMAIL_HASH_BATCH       = 500
 )
@@ -0,0 +1,10 @@
+package enums
+
+// API 密钥的权限范围，机器人只能访问声明了对应范围的路由
+// 只有已经映射到路由的范围才能授予密钥，新增范围时需要同时在路由中声明
+const (
+	SCOPE_UPLOAD_FILE = "upload-file"
+)
+
+// ApiScopes 所有可以授予 API 密钥的权限范围
+var ApiScopes = []string{SCOPE_UPLOAD_FILE}
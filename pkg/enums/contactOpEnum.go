@@ -0,0 +1,9 @@
+package enums
+
+type ContactOpEnum int8
+
+const (
+	CONTACT_ADD ContactOpEnum = iota
+	CONTACT_UPDATE
+	CONTACT_REMOVE
+)
@@ -0,0 +1,7 @@
+package enums
+
+type InviteKindEnum int8
+
+const (
+	INVITE_FRIEND InviteKindEnum = iota
+)
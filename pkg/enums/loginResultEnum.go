@@ -0,0 +1,13 @@
+package enums
+
+// 登录失败原因，记录在登录历史中，登录成功时原因为空
+const (
+	LOGIN_BAD_CREDENTIALS  = "bad_credentials"
+	LOGIN_LOCKED           = "locked"
+	LOGIN_CAPTCHA          = "captcha"
+	LOGIN_BANNED           = "banned"
+	LOGIN_DELETED          = "deleted"
+	LOGIN_EMAIL_UNVERIFIED = "email_unverified"
+	LOGIN_TWO_FACTOR       = "2fa_failed"
+	LOGIN_ERROR            = "error"
+)
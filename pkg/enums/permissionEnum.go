@@ -0,0 +1,22 @@
+package enums
+
+// 权限标识，在 RegisterRoutes 中声明到路由上，启动时同步到权限表
+const (
+	PERM_LOGIN_UNLOCK  = "login:unlock"
+	PERM_USER_BAN      = "user:ban"
+	PERM_ROLE_MANAGE   = "role:manage"
+	PERM_BOT_MANAGE    = "bot:manage"
+	PERM_LOGIN_HISTORY = "login:history"
+)
+
+// ROLE_ADMIN 内置的管理员角色，拥有全部权限
+const ROLE_ADMIN = "admin"
+
+// Permissions 所有权限标识及其说明
+var Permissions = map[string]string{
+	PERM_LOGIN_UNLOCK:  "解除登录锁定",
+	PERM_USER_BAN:      "封禁与解封用户",
+	PERM_ROLE_MANAGE:   "管理角色与权限",
+	PERM_BOT_MANAGE:    "创建与管理自己的机器人",
+	PERM_LOGIN_HISTORY: "查看所有用户的登录记录",
+}
@@ -0,0 +1,18 @@
+package enums
+
+type FriendPolicyEnum int8
+
+const (
+	FRIEND_VERIFY FriendPolicyEnum = iota
+	FRIEND_AUTO_ACCEPT
+	FRIEND_QUESTION
+	FRIEND_REFUSE
+)
+
+type LastSeenEnum int8
+
+const (
+	LAST_SEEN_EVERYONE LastSeenEnum = iota
+	LAST_SEEN_FRIENDS
+	LAST_SEEN_NOBODY
+)
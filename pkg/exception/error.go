@@ -1,28 +1,44 @@
 package exception
 
 var (
-	ErrTimeout      = NewError(1000, "请求超时")
-	ErrCheckCode    = NewError(1001, "验证码错误")
-	ErrInvalidToken = NewError(1002, "Token无效")
-	ErrTokenEmpty   = NewError(1003, "Token为空")
-	ErrUnknownAlg   = NewError(1004, "未知的加密算法")
-	ErrBadRequest   = NewError(1006, "请求参数错误")
-	ErrAlreadyExist = NewError(1007, "数据已存在")
-	ErrNotFound     = NewError(1008, "数据不存在")
-	ErrPassword     = NewError(1009, "密码错误")
-	ErrAlreadyLogin = NewError(1010, "用户已登录")
-	ErrLoginTimeout = NewError(1011, "登录超时")
-	ErrUploadFile   = NewError(1012, "上传文件失败")
-	ErrFileUrl      = NewError(1013, "文件链接获取失败")
-	//ErrPermissionDenied = NewError(1014, "权限不足")
-	ErrFileDelete    = NewError(1015, "文件删除失败")
-	ErrFileUploading = NewError(1016, "文件还还不能合并")
-	ErrFileRecovery  = NewError(1017, "文件未能恢复")
+	ErrTimeout          = NewError(1000, "请求超时")
+	ErrCheckCode        = NewError(1001, "验证码错误")
+	ErrInvalidToken     = NewError(1002, "Token无效")
+	ErrTokenEmpty       = NewError(1003, "Token为空")
+	ErrUnknownAlg       = NewError(1004, "未知的加密算法")
+	ErrBadRequest       = NewError(1006, "请求参数错误")
+	ErrAlreadyExist     = NewError(1007, "数据已存在")
+	ErrNotFound         = NewError(1008, "数据不存在")
+	ErrPassword         = NewError(1009, "密码错误")
+	ErrAlreadyLogin     = NewError(1010, "用户已登录")
+	ErrLoginTimeout     = NewError(1011, "登录超时")
+	ErrUploadFile       = NewError(1012, "上传文件失败")
+	ErrFileUrl          = NewError(1013, "文件链接获取失败")
+	ErrPermissionDenied = NewError(1014, "权限不足")
+	ErrFileDelete       = NewError(1015, "文件删除失败")
+	ErrFileUploading    = NewError(1016, "文件还还不能合并")
+	ErrFileRecovery     = NewError(1017, "文件未能恢复")
+	ErrFriendRefused    = NewError(1018, "对方不接受好友申请")
+	ErrFriendAnswer     = NewError(1019, "验证问题回答错误")
+	ErrInvalidInvite    = NewError(1020, "二维码无效或已过期")
+	ErrTooManyRequests  = NewError(1021, "请求过于频繁，请稍后再试")
+	ErrRefreshReused    = NewError(1022, "登录凭证已被使用，请重新登录")
+	ErrEmailNotVerified = NewError(1023, "邮箱尚未验证")
+	ErrTwoFactorCode    = NewError(1024, "动态验证码错误")
+	ErrLoginFailed      = NewError(1025, "邮箱或密码错误")
+	ErrLoginLocked      = NewError(1026, "尝试次数过多，请稍后再试")
+	ErrAccountBanned    = NewError(1027, "账号已被封禁")
+	ErrAccountDeleted   = NewError(1028, "账号已注销，可在宽限期内恢复")
+	ErrOAuthFailed      = NewError(1029, "第三方登录失败")
+	ErrCaptchaRequired  = NewError(1030, "请完成验证码")
+	ErrInvalidApiKey    = NewError(1031, "API密钥无效")
+	ErrFeatureDisabled  = NewError(1032, "该功能未启用")
 )
 
 type PersonalError struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data,omitempty"`
 }
 
 func (e *PersonalError) Error() string {
@@ -35,3 +51,12 @@ func NewError(code int, msg string) *PersonalError {
 		Msg:  msg,
 	}
 }
+
+// WithData 返回携带附加信息的错误副本，附加信息会随失败响应一起返回，原错误不受影响
+func (e *PersonalError) WithData(data interface{}) *PersonalError {
+	return &PersonalError{
+		Code: e.Code,
+		Msg:  e.Msg,
+		Data: data,
+	}
+}
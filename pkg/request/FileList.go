@@ -0,0 +1,11 @@
+package request
+
+type FileList struct {
+	Type   string `json:"type" validate:"omitempty,oneof=image video audio file" field_error_info:"文件类型只能是image、video、audio或file"`
+	Status *int8  `json:"status" validate:"omitempty,oneof=0 1" field_error_info:"文件状态只能是0（已上传）或1（上传中）"`
+	Name   string `json:"name" validate:"omitempty,max=150" field_error_info:"文件名不超过150个字符"`
+	Sort   string `json:"sort" validate:"omitempty,oneof=date name size" field_error_info:"排序字段只能是date、name或size"`
+	Order  string `json:"order" validate:"omitempty,oneof=asc desc" field_error_info:"排序方向只能是asc或desc"`
+	Cursor string `json:"cursor" validate:"omitempty,max=512" field_error_info:"游标格式错误"`
+	Limit  int    `json:"limit" validate:"omitempty,min=1,max=100" field_error_info:"每页数量应在1~100之间"`
+}
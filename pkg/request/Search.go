@@ -1,5 +1,7 @@
 package request
 
 type UserSearch struct {
-	UserInfo string `json:"userInfo" binding:"required" validate:"required" field_error_info:"信息不能为空"`
+	UserInfo string `json:"userInfo" binding:"required" validate:"required,max=80" field_error_info:"信息不能为空"`
+	Page     int    `json:"page" validate:"omitempty,min=1" field_error_info:"页码最小为1"`
+	PageSize int    `json:"pageSize" validate:"omitempty,min=1,max=50" field_error_info:"每页数量应在1~50之间"`
 }
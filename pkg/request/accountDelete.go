@@ -0,0 +1,13 @@
+package request
+
+type AccountDelete struct {
+	Password string `json:"password" binding:"required" validate:"required" field_error_info:"密码不能为空"`
+	Code     string `json:"code" validate:"max=32" field_error_info:"开启两步验证时需要输入动态验证码或恢复码"`
+}
+
+type AccountRestore struct {
+	Email        string `json:"email" binding:"required" validate:"required,email" field_error_info:"邮箱格式不正确"`
+	Password     string `json:"password" binding:"required" validate:"required" field_error_info:"密码不能为空"`
+	CheckCodeKey string `json:"checkCodeKey" binding:"required" validate:"required" field_error_info:"请通过正常方式访问"`
+	CheckCode    string `json:"checkCode" binding:"required" validate:"required" field_error_info:"验证码不能为空"`
+}
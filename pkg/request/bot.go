@@ -0,0 +1,16 @@
+package request
+
+type BotCreate struct {
+	UserName string `json:"userName" binding:"required" validate:"required,min=8,max=32" field_error_info:"用户名长度应在8~32之间"`
+	Nickname string `json:"nickname" validate:"max=32" field_error_info:"昵称不超过32个字符"`
+}
+
+type ApiKeyCreate struct {
+	BotId  string   `json:"botId" binding:"required" validate:"required" field_error_info:"机器人ID不能为空"`
+	Name   string   `json:"name" validate:"max=64" field_error_info:"密钥名称不超过64个字符"`
+	Scopes []string `json:"scopes" binding:"required" validate:"required,min=1,dive,oneof=upload-file" field_error_info:"权限范围只能是 upload-file"`
+}
+
+type ApiKeyTarget struct {
+	KeyId string `json:"keyId" binding:"required" validate:"required,max=32" field_error_info:"密钥ID不能为空"`
+}
@@ -0,0 +1,5 @@
+package request
+
+type ContactDiscover struct {
+	Hashes []string `json:"hashes" binding:"required" validate:"required,min=1,max=500,dive,len=64,hexadecimal" field_error_info:"邮箱哈希格式错误或数量不在1~500之间"`
+}
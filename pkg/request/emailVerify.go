@@ -0,0 +1,12 @@
+package request
+
+type EmailVerify struct {
+	Email string `json:"email" binding:"required" validate:"required,email" field_error_info:"邮箱格式不正确"`
+	Code  string `json:"code" binding:"required" validate:"required,len=6,numeric" field_error_info:"验证码为6位数字"`
+}
+
+type EmailResend struct {
+	Email        string `json:"email" binding:"required" validate:"required,email" field_error_info:"邮箱格式不正确"`
+	CheckCodeKey string `json:"checkCodeKey" binding:"required" validate:"required" field_error_info:"请通过正常方式访问"`
+	CheckCode    string `json:"checkCode" binding:"required" validate:"required" field_error_info:"验证码不能为空"`
+}
@@ -2,4 +2,5 @@ package request
 
 type FriendRequest struct {
 	FriendInfo string `json:"friendInfo" binding:"required" validate:"required" field_error_info:"好友信息不能为空"`
+	Answer     string `json:"answer" validate:"max=150" field_error_info:"验证答案不超过150个字符"`
 }
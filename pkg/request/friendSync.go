@@ -0,0 +1,5 @@
+package request
+
+type FriendSync struct {
+	Version int64 `json:"version" validate:"min=0" field_error_info:"版本号不能小于0"`
+}
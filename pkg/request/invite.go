@@ -0,0 +1,11 @@
+package request
+
+// InviteCreate 目前只支持添加好友的邀请（Kind 为 0），群组实现之后再开放加群邀请
+type InviteCreate struct {
+	Kind int8 `json:"kind" validate:"oneof=0" field_error_info:"邀请类型错误"`
+}
+
+type InviteRedeem struct {
+	Token  string `json:"token" binding:"required" validate:"required" field_error_info:"邀请令牌不能为空"`
+	Answer string `json:"answer" validate:"max=150" field_error_info:"验证答案不超过150个字符"`
+}
@@ -2,7 +2,9 @@ package request
 
 type Login struct {
 	Email        string `json:"email" binding:"required" validate:"required,email" field_error_info:"邮箱格式不正确"`
-	Password     string `json:"password" binding:"required" validate:"required,pass" field_error_info:"密码格式应是8~32位且有大小写字母"`
-	CheckCodeKey string `json:"checkCodeKey" binding:"required" validate:"required" field_error_info:"请通过正常方式访问"`
-	CheckCode    string `json:"checkCode" binding:"required" validate:"required" field_error_info:"验证码不能为空"`
+	Password     string `json:"password" binding:"required" validate:"required,max=128" field_error_info:"密码不能为空"`
+	CheckCodeKey string `json:"checkCodeKey" validate:"max=64" field_error_info:"请通过正常方式访问"`
+	CheckCode    string `json:"checkCode" validate:"max=64" field_error_info:"验证码格式不正确"`
+	Device       string `json:"device" validate:"max=64" field_error_info:"设备名称不超过64个字符"`
+	DeviceId     string `json:"deviceId" validate:"max=128" field_error_info:"设备标识不超过128个字符"`
 }
@@ -0,0 +1,15 @@
+package request
+
+type LoginHistory struct {
+	Page     int `json:"page" validate:"omitempty,min=1" field_error_info:"页码最小为1"`
+	PageSize int `json:"pageSize" validate:"omitempty,min=1,max=50" field_error_info:"每页数量应在1~50之间"`
+}
+
+type AdminLoginHistory struct {
+	UserId     string `json:"userId" validate:"omitempty,max=150" field_error_info:"用户ID格式错误"`
+	Email      string `json:"email" validate:"omitempty,max=255" field_error_info:"邮箱格式错误"`
+	Ip         string `json:"ip" validate:"omitempty,ip" field_error_info:"IP地址格式错误"`
+	Suspicious bool   `json:"suspicious"`
+	Page       int    `json:"page" validate:"omitempty,min=1" field_error_info:"页码最小为1"`
+	PageSize   int    `json:"pageSize" validate:"omitempty,min=1,max=50" field_error_info:"每页数量应在1~50之间"`
+}
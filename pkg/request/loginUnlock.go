@@ -0,0 +1,6 @@
+package request
+
+type LoginUnlock struct {
+	Email string `json:"email" validate:"required_without=Ip,omitempty,email" field_error_info:"请填写正确的邮箱或IP"`
+	Ip    string `json:"ip" validate:"required_without=Email,omitempty,ip" field_error_info:"请填写正确的邮箱或IP"`
+}
@@ -0,0 +1,10 @@
+package request
+
+type OAuthAuthorize struct {
+	Device string `form:"device" validate:"max=64" field_error_info:"设备名称不超过64个字符"`
+}
+
+type OAuthCallback struct {
+	State string `json:"state" binding:"required" validate:"required" field_error_info:"state不能为空"`
+	Code  string `json:"code" binding:"required" validate:"required" field_error_info:"授权码不能为空"`
+}
@@ -0,0 +1,12 @@
+package request
+
+type PasswordForgot struct {
+	Email        string `json:"email" binding:"required" validate:"required,email" field_error_info:"邮箱格式不正确"`
+	CheckCodeKey string `json:"checkCodeKey" binding:"required" validate:"required" field_error_info:"请通过正常方式访问"`
+	CheckCode    string `json:"checkCode" binding:"required" validate:"required" field_error_info:"验证码不能为空"`
+}
+
+type PasswordReset struct {
+	Token    string `json:"token" binding:"required" validate:"required" field_error_info:"重置令牌不能为空"`
+	Password string `json:"password" binding:"required" validate:"required,pass,notbreached" field_error_info:"密码不符合要求"`
+}
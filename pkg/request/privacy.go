@@ -0,0 +1,10 @@
+package request
+
+type Privacy struct {
+	SearchByEmail      bool   `json:"searchByEmail"`
+	SearchByUsername   bool   `json:"searchByUsername"`
+	FriendPolicy       int8   `json:"friendPolicy" validate:"min=0,max=3" field_error_info:"好友申请策略不正确"`
+	Question           string `json:"question" validate:"required_if=FriendPolicy 2,max=150" field_error_info:"验证问题不能为空且不超过150个字符"`
+	Answer             string `json:"answer" validate:"required_if=FriendPolicy 2,max=150" field_error_info:"验证答案不能为空且不超过150个字符"`
+	LastSeenVisibility int8   `json:"lastSeenVisibility" validate:"min=0,max=2" field_error_info:"最后在线时间可见范围不正确"`
+}
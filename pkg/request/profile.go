@@ -0,0 +1,17 @@
+package request
+
+type ProfileUpdate struct {
+	Username string `json:"username" validate:"omitempty,min=8,max=32" field_error_info:"用户名长度应在8~32之间"`
+	Nickname string `json:"nickname" validate:"max=32" field_error_info:"昵称不超过32个字符"`
+}
+
+type PasswordChange struct {
+	OldPassword string `json:"oldPassword" binding:"required" validate:"required" field_error_info:"原密码不能为空"`
+	NewPassword string `json:"newPassword" binding:"required" validate:"required,pass,notbreached" field_error_info:"密码不符合要求"`
+}
+
+type AvatarCrop struct {
+	X    int `form:"x" validate:"min=0" field_error_info:"裁剪区域错误"`
+	Y    int `form:"y" validate:"min=0" field_error_info:"裁剪区域错误"`
+	Size int `form:"size" validate:"min=0" field_error_info:"裁剪区域错误"`
+}
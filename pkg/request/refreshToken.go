@@ -0,0 +1,5 @@
+package request
+
+type RefreshToken struct {
+	RefreshToken string `json:"refreshToken" binding:"required" validate:"required" field_error_info:"刷新令牌不能为空"`
+}
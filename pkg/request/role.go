@@ -0,0 +1,12 @@
+package request
+
+type RoleSave struct {
+	Name        string   `json:"name" binding:"required" validate:"required,max=64" field_error_info:"角色名称不能为空且不超过64个字符"`
+	Description string   `json:"description" validate:"max=255" field_error_info:"角色说明不超过255个字符"`
+	Permissions []string `json:"permissions" validate:"max=64,dive,max=64" field_error_info:"权限列表格式错误"`
+}
+
+type RoleAssign struct {
+	UserId string `json:"userId" binding:"required" validate:"required" field_error_info:"用户ID不能为空"`
+	Role   string `json:"role" binding:"required" validate:"required,max=64" field_error_info:"角色名称不能为空"`
+}
@@ -0,0 +1,5 @@
+package request
+
+type SessionRevoke struct {
+	SessionId string `json:"sessionId" binding:"required" validate:"required" field_error_info:"会话ID不能为空"`
+}
@@ -0,0 +1,15 @@
+package request
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required" validate:"required,len=6,numeric" field_error_info:"动态验证码为6位数字"`
+}
+
+type TwoFactorDisable struct {
+	Password string `json:"password" binding:"required" validate:"required" field_error_info:"密码不能为空"`
+	Code     string `json:"code" binding:"required" validate:"required,max=32" field_error_info:"请输入动态验证码或恢复码"`
+}
+
+type TwoFactorLogin struct {
+	Challenge string `json:"challenge" binding:"required" validate:"required" field_error_info:"登录挑战不能为空"`
+	Code      string `json:"code" binding:"required" validate:"required,max=32" field_error_info:"请输入动态验证码或恢复码"`
+}
@@ -0,0 +1,13 @@
+package request
+
+import "time"
+
+type UserBan struct {
+	UserId string     `json:"userId" binding:"required" validate:"required" field_error_info:"用户ID不能为空"`
+	Reason string     `json:"reason" binding:"required" validate:"required,max=255" field_error_info:"封禁原因不能为空且不超过255个字符"`
+	Until  *time.Time `json:"until" field_error_info:"封禁截止时间格式错误"`
+}
+
+type UserUnban struct {
+	UserId string `json:"userId" binding:"required" validate:"required" field_error_info:"用户ID不能为空"`
+}
@@ -25,7 +25,7 @@ func Fail(err error) Response {
 		return Response{
 			Code: value.Code,
 			Msg:  value.Error(),
-			Data: nil,
+			Data: value.Data,
 		}
 	}
 	return Response{
@@ -0,0 +1,240 @@
+package token
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/types"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signingKey 一个签名或验签密钥，Private 为空时只用于验证轮换前签发的令牌
+type signingKey struct {
+	Kid     string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// keySet 当前使用的密钥集合
+// active 用于签发新令牌，keys 中的所有密钥都可以验签，legacy 为旧的 HS256 共享密钥
+type keySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+	legacy []byte
+}
+
+var errUnsupportedKey = errors.New("unsupported jwt key")
+
+// loadKeySet 从 JWT_KEY_DIR 目录加载 PEM 格式的密钥，文件名（不含扩展名）即 kid
+// 私钥可以签名也可以验签，只有公钥的文件用于验证轮换前签发的令牌。
+// 轮换密钥时先把新公钥分发到所有实例，再把 JWT_ACTIVE_KID 切换到新私钥，
+// 旧密钥保留到其签发的令牌全部过期后再删除。
+// 未配置 JWT_KEY_DIR 时退回到 JWT_SECRET 的 HS256 签名；两者同时配置时，
+// JWT_SECRET 只用于验证迁移之前签发的令牌。
+func loadKeySet(dir, activeKid, alg string, secret []byte) (*keySet, error) {
+	set := &keySet{keys: make(map[string]*signingKey), legacy: secret}
+	if dir == "" {
+		if len(secret) == 0 {
+			return nil, errors.New("JWT_SECRET or JWT_KEY_DIR must be set")
+		}
+		return set, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseKey(strings.TrimSuffix(filepath.Base(file), ".pem"), data)
+		if err != nil {
+			return nil, errors.New(file + ": " + err.Error())
+		}
+		set.keys[key.Kid] = key
+	}
+	// 目录中没有任何密钥时生成一个，便于开发环境直接启动
+	if len(set.keys) == 0 {
+		key, err := generateKey(dir, alg)
+		if err != nil {
+			return nil, err
+		}
+		set.keys[key.Kid] = key
+	}
+	if activeKid == "" {
+		activeKid = newestKid(set.keys)
+	}
+	active, ok := set.keys[activeKid]
+	if !ok || active.Private == nil {
+		return nil, errors.New("JWT_ACTIVE_KID " + activeKid + " has no private key")
+	}
+	set.active = active
+	return set, nil
+}
+
+// parseKey 解析 PKCS#8、PKCS#1 私钥或 PKIX 公钥，支持 RSA 与 Ed25519
+func parseKey(kid string, data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errUnsupportedKey
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errUnsupportedKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := &signingKey{Kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errUnsupportedKey
+	}
+	if k, ok := key.Public.(*rsa.PublicKey); ok && k.N.BitLen() < 2048 {
+		return nil, errors.New("rsa key must be at least 2048 bits")
+	}
+	return key, nil
+}
+
+// generateKey 生成新的私钥并以 PKCS#8 格式保存，kid 为生成时间
+func generateKey(dir, alg string) (*signingKey, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case "", jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, errors.New("JWT_SIGNING_ALG must be RS256 or EdDSA")
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	kid := strconv.FormatInt(time.Now().Unix(), 10)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		return nil, err
+	}
+	return parseKey(kid, data)
+}
+
+// newestKid 未指定 JWT_ACTIVE_KID 时使用 kid 排序最大的私钥签名
+func newestKid(keys map[string]*signingKey) string {
+	kids := make([]string, 0, len(keys))
+	for kid, key := range keys {
+		if key.Private != nil {
+			kids = append(kids, kid)
+		}
+	}
+	if len(kids) == 0 {
+		return ""
+	}
+	sort.Strings(kids)
+	return kids[len(kids)-1]
+}
+
+// sign 使用当前的签名密钥签发令牌
+func (set *keySet) sign(claims jwt.Claims) (string, error) {
+	if set.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(set.legacy)
+	}
+	token := jwt.NewWithClaims(set.active.Method, claims)
+	token.Header["kid"] = set.active.Kid
+	return token.SignedString(set.active.Private)
+}
+
+// keyFunc 根据令牌头部的 kid 选择验签密钥，并确认签名算法与密钥类型一致
+func (set *keySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if kid, ok := token.Header["kid"].(string); ok {
+		key, ok := set.keys[kid]
+		if !ok || token.Method.Alg() != key.Method.Alg() {
+			return nil, exception.ErrUnknownAlg
+		}
+		return key.Public, nil
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && len(set.legacy) != 0 {
+		return set.legacy, nil
+	}
+	return nil, exception.ErrUnknownAlg
+}
+
+// validMethods 允许的签名算法，防止算法混淆
+func (set *keySet) validMethods() []string {
+	methods := make([]string, 0, 3)
+	if len(set.legacy) != 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	seen := make(map[string]bool)
+	for _, key := range set.keys {
+		if !seen[key.Method.Alg()] {
+			seen[key.Method.Alg()] = true
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+	return methods
+}
+
+// jwks 以 RFC 7517 格式导出所有验签公钥，HS256 共享密钥不会被导出
+func (set *keySet) jwks() types.JWKS {
+	kids := make([]string, 0, len(set.keys))
+	for kid := range set.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	jwks := types.JWKS{Keys: make([]types.JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := set.keys[kid]
+		jwk := types.JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch k := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
@@ -0,0 +1,39 @@
+package token
+
+import (
+	"Gin-IM/pkg/exception"
+	"Gin-IM/pkg/types"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey 当前请求的身份在 gin 上下文中的键
+const principalKey = "principal"
+
+// ExtractApiKey 返回请求头 X-Api-Key 中的机器人 API 密钥
+func ExtractApiKey(ctx *gin.Context) string {
+	return ctx.GetHeader("X-Api-Key")
+}
+
+// SetPrincipal 由认证中间件在校验令牌与会话后调用
+func SetPrincipal(ctx *gin.Context, principal *types.Principal) {
+	ctx.Set(principalKey, principal)
+}
+
+// GetPrincipal 返回认证中间件加载的身份，跳过认证的路由上返回 ErrTokenEmpty
+func GetPrincipal(ctx *gin.Context) (*types.Principal, error) {
+	if value, ok := ctx.Get(principalKey); ok {
+		if principal, ok := value.(*types.Principal); ok {
+			return principal, nil
+		}
+	}
+	return nil, exception.ErrTokenEmpty
+}
+
+// CurrentClaims 返回当前请求的令牌声明，令牌与会话已由认证中间件校验
+func CurrentClaims(ctx *gin.Context) (*types.GIClaims, error) {
+	principal, err := GetPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return principal.Claims, nil
+}
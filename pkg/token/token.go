@@ -1,6 +1,7 @@
 package token
 
 import (
+	"Gin-IM/pkg/defines"
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/types"
 	"github.com/gin-gonic/gin"
@@ -11,18 +12,18 @@ import (
 	"strings"
 )
 
-var apiSecret []byte
+var keys *keySet
 
 func init() {
-	apiSecret = []byte(os.Getenv("JWT_SECRET"))
-	if len(apiSecret) == 0 {
-		log.Logger.Panic().Msg("JWT_SECRET is not set")
+	var err error
+	keys, err = loadKeySet(os.Getenv("JWT_KEY_DIR"), os.Getenv("JWT_ACTIVE_KID"), os.Getenv("JWT_SIGNING_ALG"), []byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		log.Logger.Panic().Err(err).Msg("加载JWT密钥失败")
 	}
 }
 
 func GernerateToken(claims jwt.Claims) string {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	if tokenString, err := token.SignedString(apiSecret); err == nil {
+	if tokenString, err := keys.sign(claims); err == nil {
 		return tokenString
 	} else {
 		log.Logger.Error().Err(err).Msg("GernerateToken error")
@@ -35,12 +36,7 @@ func TokenValid(ctx *gin.Context) error {
 	if tokenString == "" {
 		return exception.ErrTokenEmpty
 	}
-	tokens, err := jwt.ParseWithClaims(tokenString, &types.GIClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, exception.ErrUnknownAlg
-		}
-		return apiSecret, nil
-	})
+	tokens, err := jwt.ParseWithClaims(tokenString, &types.GIClaims{}, keys.keyFunc, accessTokenOptions()...)
 	if err != nil {
 		return err
 	}
@@ -50,6 +46,16 @@ func TokenValid(ctx *gin.Context) error {
 	return exception.ErrInvalidToken
 }
 
+// accessTokenOptions 访问令牌的校验选项，要求签发方、受众与有效期，邀请令牌等其他令牌不能通过校验
+func accessTokenOptions() []jwt.ParserOption {
+	return []jwt.ParserOption{
+		jwt.WithValidMethods(keys.validMethods()),
+		jwt.WithIssuer(defines.TOKEN_ISSUER),
+		jwt.WithAudience(defines.ACCESS_AUDIENCE),
+		jwt.WithExpirationRequired(),
+	}
+}
+
 func ExtractToken(ctx *gin.Context) string {
 	bearerToken := ctx.GetHeader("Authorization")
 	if len(strings.Split(bearerToken, " ")) == 2 {
@@ -63,12 +69,7 @@ func ExtractClaims(ctx *gin.Context) (*types.GIClaims, error) {
 	if tokenString == "" {
 		return nil, exception.ErrTokenEmpty
 	}
-	tokens, err := jwt.ParseWithClaims(tokenString, &types.GIClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, exception.ErrUnknownAlg
-		}
-		return apiSecret, nil
-	})
+	tokens, err := jwt.ParseWithClaims(tokenString, &types.GIClaims{}, keys.keyFunc, accessTokenOptions()...)
 	if err != nil {
 		return nil, exception.ErrInvalidToken
 	}
@@ -78,3 +79,21 @@ func ExtractClaims(ctx *gin.Context) (*types.GIClaims, error) {
 	}
 	return nil, exception.ErrInvalidToken
 }
+
+// ParseInviteToken 校验二维码邀请令牌的签名、有效期与受众，返回其中的邀请信息
+func ParseInviteToken(tokenString string) (*types.InviteClaims, error) {
+	tokens, err := jwt.ParseWithClaims(tokenString, &types.InviteClaims{}, keys.keyFunc, jwt.WithValidMethods(keys.validMethods()), jwt.WithAudience(defines.INVITE_AUDIENCE), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, exception.ErrInvalidInvite
+	}
+	claim, ok := tokens.Claims.(*types.InviteClaims)
+	if ok && tokens.Valid && len(claim.Target) != 0 {
+		return claim, nil
+	}
+	return nil, exception.ErrInvalidInvite
+}
+
+// JWKS 返回所有验签公钥，供其他服务在本地校验令牌
+func JWKS() types.JWKS {
+	return keys.jwks()
+}
@@ -0,0 +1,8 @@
+package types
+
+import "time"
+
+// AccountDeletion 已注销账号的信息，PurgeAt 之前可以恢复
+type AccountDeletion struct {
+	PurgeAt time.Time `json:"purgeAt"`
+}
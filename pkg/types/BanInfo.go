@@ -0,0 +1,9 @@
+package types
+
+import "time"
+
+// BanInfo 账号封禁信息，Until 为空表示永久封禁
+type BanInfo struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
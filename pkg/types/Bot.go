@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+type BotInfo struct {
+	Uuid      string       `json:"uuid"`
+	Username  string       `json:"username"`
+	Nickname  string       `json:"nickname"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Keys      []ApiKeyInfo `json:"keys"`
+}
+
+type ApiKeyInfo struct {
+	KeyId      string     `json:"keyId"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIp string     `json:"lastUsedIp"`
+}
+
+// ApiKeySecret 新创建或轮换后的密钥，Key 只返回这一次
+type ApiKeySecret struct {
+	KeyId string `json:"keyId"`
+	Key   string `json:"key"`
+}
@@ -1,6 +1,9 @@
 package types
 
 type CaptDateBase64 struct {
-	Id   string `json:"id"`
-	B64s string `json:"b64s"`
+	Id         string `json:"id"`
+	Type       string `json:"type"`
+	B64s       string `json:"b64s,omitempty"`
+	Challenge  string `json:"challenge,omitempty"`
+	Difficulty int    `json:"difficulty,omitempty"`
 }
@@ -0,0 +1,7 @@
+package types
+
+// DiscoveredContact 通讯录发现的匹配结果，Hash 为客户端上传的邮箱哈希
+type DiscoveredContact struct {
+	Hash string `json:"hash"`
+	PublicProfile
+}
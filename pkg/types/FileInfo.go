@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+// FileInfo 文件库中的一个文件，Size 与 ContentType 取自对象存储中的元数据
+type FileInfo struct {
+	Md5         string    `json:"md5"`
+	Sha1        string    `json:"sha1"`
+	FileName    string    `json:"fileName"`
+	FileType    string    `json:"fileType"`
+	Status      int8      `json:"status"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// FileList 按游标分页的文件列表，NextCursor 为空表示没有更多文件
+type FileList struct {
+	Files      []FileInfo `json:"files"`
+	NextCursor string     `json:"nextCursor"`
+}
@@ -0,0 +1,6 @@
+package types
+
+type FriendSuggestion struct {
+	PublicProfile
+	Mutual int64 `json:"mutual" gorm:"column:mutual"`
+}
@@ -0,0 +1,13 @@
+package types
+
+type ContactChange struct {
+	Op     int8   `json:"op"`
+	Friend Friend `json:"friend"`
+}
+
+type FriendSync struct {
+	Version int64           `json:"version"`
+	Full    bool            `json:"full"`
+	Friends []Friend        `json:"friends,omitempty"`
+	Changes []ContactChange `json:"changes,omitempty"`
+}
@@ -3,7 +3,7 @@ package types
 import "github.com/golang-jwt/jwt/v5"
 
 type GIClaims struct {
-	UserId string `json:"userId"`
-	Admin  bool   `json:"admin"`
+	UserId    string `json:"userId"`
+	SessionId string `json:"sessionId"`
 	jwt.RegisteredClaims
 }
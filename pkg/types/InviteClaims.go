@@ -0,0 +1,10 @@
+package types
+
+import "github.com/golang-jwt/jwt/v5"
+
+// InviteClaims 二维码邀请令牌的声明，Kind 为邀请类型，Target 为被添加的用户ID
+type InviteClaims struct {
+	Kind   int8   `json:"kind"`
+	Target string `json:"target"`
+	jwt.RegisteredClaims
+}
@@ -0,0 +1,9 @@
+package types
+
+import "time"
+
+type InviteQRCode struct {
+	Token     string    `json:"token"`
+	B64s      string    `json:"b64s"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
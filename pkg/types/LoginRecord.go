@@ -0,0 +1,17 @@
+package types
+
+import "time"
+
+// LoginRecord 返回给用户与管理员的登录记录，Reason 为失败原因，Suspicious 表示来自新设备或新网段
+type LoginRecord struct {
+	Id         uint      `json:"id"`
+	UserId     string    `json:"userId"`
+	Email      string    `json:"email"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	Device     string    `json:"device"`
+	Success    bool      `json:"success"`
+	Reason     string    `json:"reason"`
+	Suspicious bool      `json:"suspicious"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
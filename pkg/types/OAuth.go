@@ -0,0 +1,15 @@
+package types
+
+// OAuthAuthorize 第三方登录的授权地址，客户端跳转到 AuthUrl，回调后把 state 与 code 提交给服务端
+type OAuthAuthorize struct {
+	AuthUrl string `json:"authUrl"`
+	State   string `json:"state"`
+}
+
+// OAuthState 授权请求期间保存在服务端的状态，code_verifier 不会发送给客户端
+type OAuthState struct {
+	Provider string `json:"provider"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Device   string `json:"device"`
+}
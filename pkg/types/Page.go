@@ -0,0 +1,8 @@
+package types
+
+type Page[T any] struct {
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"pageSize"`
+	List     []T   `json:"list"`
+}
@@ -0,0 +1,21 @@
+package types
+
+import "slices"
+
+// Principal 当前请求的身份，由认证中间件在每次请求中加载一次
+// 机器人通过 API 密钥访问时 Scopes 为密钥的权限范围，Permissions 为空
+type Principal struct {
+	Claims      *GIClaims
+	Permissions []string
+	Scopes      []string
+}
+
+// Can 是否拥有全部指定的权限
+func (p *Principal) Can(permissions ...string) bool {
+	for _, permission := range permissions {
+		if !slices.Contains(p.Permissions, permission) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,9 @@
+package types
+
+type Privacy struct {
+	SearchByEmail      bool   `json:"searchByEmail"`
+	SearchByUsername   bool   `json:"searchByUsername"`
+	FriendPolicy       int8   `json:"friendPolicy"`
+	Question           string `json:"question"`
+	LastSeenVisibility int8   `json:"lastSeenVisibility"`
+}
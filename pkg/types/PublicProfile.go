@@ -0,0 +1,12 @@
+package types
+
+import "time"
+
+// PublicProfile 向其他用户展示时使用的用户信息，不包含邮箱、密码等隐私字段
+type PublicProfile struct {
+	Uuid     string     `json:"uuid" gorm:"column:uuid"`
+	Username string     `json:"username" gorm:"column:username"`
+	Nickname string     `json:"nickname" gorm:"column:nickname"`
+	Avatar   string     `json:"avatar" gorm:"column:avatar"`
+	LastSeen *time.Time `json:"lastSeen,omitempty" gorm:"column:last_seen"`
+}
@@ -0,0 +1,15 @@
+package types
+
+import "time"
+
+// Session 保存在 Valkey 中的登录会话，每个设备登录一次产生一个会话
+type Session struct {
+	SessionId   string    `json:"sessionId"`
+	UserId      string    `json:"userId"`
+	Device      string    `json:"device"`
+	Ip          string    `json:"ip"`
+	UserAgent   string    `json:"userAgent"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastSeen    time.Time `json:"lastSeen"`
+	RefreshHash string    `json:"refreshHash"`
+}
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// SessionInfo 返回给用户的设备会话信息，Current 表示是否为发起请求的会话
+type SessionInfo struct {
+	SessionId string    `json:"sessionId"`
+	Device    string    `json:"device"`
+	Ip        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Current   bool      `json:"current"`
+}
@@ -0,0 +1,11 @@
+package types
+
+import "time"
+
+// TokenPair 登录或刷新后返回的令牌，AccessToken 为短期有效的 JWT，RefreshToken 每次刷新后都会更换
+type TokenPair struct {
+	SessionId    string    `json:"sessionId"`
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
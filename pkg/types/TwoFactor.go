@@ -0,0 +1,15 @@
+package types
+
+// TwoFactorSetup 开启两步验证时返回的密钥，B64s 为 otpauth 地址的二维码
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+	B64s   string `json:"b64s"`
+}
+
+// LoginResult 登录结果，开启了两步验证时只返回 Challenge，需要再提交动态验证码换取令牌
+type LoginResult struct {
+	TwoFactorRequired bool       `json:"twoFactorRequired"`
+	Challenge         string     `json:"challenge,omitempty"`
+	Tokens            *TokenPair `json:"tokens,omitempty"`
+}
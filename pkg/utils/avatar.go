@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"Gin-IM/pkg/defines"
+	"bytes"
+	"errors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+)
+
+// ErrImageTooLarge 图片像素尺寸超出限制，防止解码超大图片耗尽内存
+var ErrImageTooLarge = errors.New("image too large")
+
+// CropAvatar 解码图片并裁剪为正方形，再缩放为 AVATAR_SIZE 大小的 PNG
+// size 为 0 时从图片中心裁剪最大的正方形，否则以 (x, y) 为左上角裁剪边长为 size 的正方形
+func CropAvatar(data []byte, x, y, size int) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// 按总像素数限制，细长的图片单边很大时同样会占用大量内存
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > defines.AVATAR_MAX_PIXELS {
+		return nil, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	if size <= 0 {
+		size = min(bounds.Dx(), bounds.Dy())
+		x = (bounds.Dx() - size) / 2
+		y = (bounds.Dy() - size) / 2
+	}
+	crop := image.Rect(x, y, x+size, y+size).Add(bounds.Min).Intersect(bounds)
+	if crop.Empty() {
+		return nil, image.ErrFormat
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, defines.AVATAR_SIZE, defines.AVATAR_SIZE))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadLimited 读取至多 limit 字节，超出时返回错误
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrImageTooLarge
+	}
+	return data, nil
+}
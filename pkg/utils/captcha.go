@@ -3,28 +3,87 @@ package utils
 import (
 	"Gin-IM/pkg/exception"
 	"Gin-IM/pkg/types"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	_ "github.com/joho/godotenv/autoload"
 	"github.com/mojocn/base64Captcha"
 	"image/color"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
 )
 
+// 验证码类型，通过环境变量 CAPTCHA_DRIVER 选择，默认为 math
+const (
+	CaptchaDigit  = "digit"
+	CaptchaString = "string"
+	CaptchaMath   = "math"
+	CaptchaAudio  = "audio"
+	CaptchaPow    = "pow"
+)
+
+var (
+	captchaDriver = strings.ToLower(strings.TrimSpace(os.Getenv("CAPTCHA_DRIVER")))
+	// powDifficulty 工作量证明要求哈希值前导零的位数，每增加 1 位客户端的平均计算量翻倍
+	powDifficulty = 20
+)
+
+var captchaBgColor = &color.RGBA{
+	R: 254,
+	G: 254,
+	B: 254,
+	A: 254,
+}
+
+func init() {
+	if difficulty, err := strconv.Atoi(os.Getenv("CAPTCHA_POW_DIFFICULTY")); err == nil && difficulty > 0 && difficulty <= 32 {
+		powDifficulty = difficulty
+	}
+	switch captchaDriver {
+	case CaptchaDigit, CaptchaString, CaptchaAudio, CaptchaPow:
+	default:
+		captchaDriver = CaptchaMath
+	}
+}
+
 type Captcha struct {
 	store  base64Captcha.Store
+	kind   string
 	driver base64Captcha.Driver
 }
 
 func NewCaptcha(store base64Captcha.Store) *Captcha {
-	mathDriver := base64Captcha.NewDriverMath(40, 160, 5, base64Captcha.OptionShowSineLine, &color.RGBA{
-		R: 254,
-		G: 254,
-		B: 254,
-		A: 254,
-	}, base64Captcha.DefaultEmbeddedFonts, []string{"wqy-microhei.ttc"})
+	var driver base64Captcha.Driver
+	switch captchaDriver {
+	case CaptchaDigit:
+		driver = base64Captcha.NewDriverDigit(40, 160, 5, 0.7, 80)
+	case CaptchaString:
+		driver = base64Captcha.NewDriverString(40, 160, 0, base64Captcha.OptionShowSineLine, 5, base64Captcha.TxtSimpleCharaters, captchaBgColor, base64Captcha.DefaultEmbeddedFonts, []string{"wqy-microhei.ttc"})
+	case CaptchaAudio:
+		language := os.Getenv("CAPTCHA_AUDIO_LANGUAGE")
+		if language == "" {
+			language = "zh"
+		}
+		driver = base64Captcha.NewDriverAudio(6, language)
+	case CaptchaMath:
+		driver = base64Captcha.NewDriverMath(40, 160, 5, base64Captcha.OptionShowSineLine, captchaBgColor, base64Captcha.DefaultEmbeddedFonts, []string{"wqy-microhei.ttc"})
+	}
 	return &Captcha{
 		store:  store,
-		driver: mathDriver,
+		kind:   captchaDriver,
+		driver: driver,
 	}
 }
+
+// Generate 生成验证码
+// 图片与音频验证码返回 base64 编码的内容；工作量证明返回随机挑战与难度，
+// 客户端需要找到一个答案，使 SHA-256(challenge + answer) 的前 difficulty 位均为 0
 func (c *Captcha) Generate() (*types.CaptDateBase64, error) {
+	if c.kind == CaptchaPow {
+		return c.generatePow()
+	}
 	capt := base64Captcha.NewCaptcha(c.driver, c.store)
 	id, b64s, _, err := capt.Generate()
 	if err != nil {
@@ -32,6 +91,7 @@ func (c *Captcha) Generate() (*types.CaptDateBase64, error) {
 	}
 	return &types.CaptDateBase64{
 		Id:   id,
+		Type: c.kind,
 		B64s: b64s,
 	}, nil
 }
@@ -40,8 +100,48 @@ func (c *Captcha) Verify(id, answer string, clear bool) error {
 	if len(id) == 0 || len(answer) == 0 {
 		return exception.ErrCheckCode
 	}
+	if c.kind == CaptchaPow {
+		return c.verifyPow(id, answer, clear)
+	}
 	if !base64Captcha.NewCaptcha(c.driver, c.store).Verify(id, answer, clear) {
 		return exception.ErrCheckCode
 	}
 	return nil
 }
+
+func (c *Captcha) generatePow() (*types.CaptDateBase64, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, exception.ErrCheckCode
+	}
+	id, challenge := base64Captcha.RandomId(), hex.EncodeToString(buf)
+	if err := c.store.Set(id, challenge); err != nil {
+		return nil, exception.ErrCheckCode
+	}
+	return &types.CaptDateBase64{
+		Id:         id,
+		Type:       CaptchaPow,
+		Challenge:  challenge,
+		Difficulty: powDifficulty,
+	}, nil
+}
+
+// verifyPow 挑战只能使用一次，校验失败同样会被清除，避免针对同一挑战反复尝试
+func (c *Captcha) verifyPow(id, answer string, clear bool) error {
+	challenge := c.store.Get(id, clear)
+	if challenge == "" || len(answer) > 64 {
+		return exception.ErrCheckCode
+	}
+	sum := sha256.Sum256([]byte(challenge + answer))
+	zeros := 0
+	for _, b := range sum {
+		zeros += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	if zeros < powDifficulty {
+		return exception.ErrCheckCode
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+)
+
+var discoverySalt = os.Getenv("CONTACT_DISCOVERY_SALT")
+
+// DiscoverySalt 返回通讯录发现使用的盐值，客户端需要使用相同的盐值计算邮箱哈希
+func DiscoverySalt() string {
+	return discoverySalt
+}
+
+// HashEmail 计算加盐后的邮箱 SHA-256 哈希（十六进制小写），邮箱会先去除首尾空白并转为小写
+func HashEmail(email string) string {
+	sum := sha256.Sum256([]byte(discoverySalt + strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,5 +1,10 @@
 package utils
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 func GetFileType(fileSuffix string) string {
 	switch fileSuffix {
 	case "jpg", "jpeg", "png", "gif", "bmp":
@@ -24,3 +29,8 @@ func GetFileTypeEnum(fileType string) int8 {
 		return 4
 	}
 }
+
+// FileTypeOf 根据文件名的扩展名判断文件类型，扩展名不区分大小写
+func FileTypeOf(fileName string) string {
+	return GetFileType(strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), ".")))
+}
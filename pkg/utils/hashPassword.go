@@ -1,31 +1,130 @@
 package utils
 
 import (
-	"github.com/rs/zerolog/log"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	_ "github.com/joho/godotenv/autoload"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 	"os"
 	"strconv"
+	"strings"
 )
 
-var cost int
+// 密码哈希算法，通过环境变量 PASSWORD_HASH_ALG 选择，默认为 argon2id
+const (
+	HashArgon2id = "argon2id"
+	HashBcrypt   = "bcrypt"
+)
+
+var ErrInvalidHash = errors.New("密码哈希格式错误")
+
+// argon2Params argon2id 的参数，与哈希一起编码保存，修改配置后已有的哈希仍然可以校验
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	saltLen int
+	keyLen  uint32
+}
+
+var (
+	hashAlg = HashArgon2id
+	// cost bcrypt 的计算成本，由 HASH_SALT 配置
+	cost = bcrypt.DefaultCost
+	// params 默认值为 RFC 9106 中内存受限环境的推荐参数，可以通过 ARGON2_MEMORY（KiB）、ARGON2_TIME、ARGON2_THREADS 调整
+	params = argon2Params{memory: 64 * 1024, time: 3, threads: 4, saltLen: 16, keyLen: 32}
+)
 
 func init() {
-	if val, err := strconv.Atoi(os.Getenv("HASH_SALT")); err == nil {
+	if os.Getenv("PASSWORD_HASH_ALG") == HashBcrypt {
+		hashAlg = HashBcrypt
+	}
+	if val, err := strconv.Atoi(os.Getenv("HASH_SALT")); err == nil && val >= bcrypt.MinCost && val <= bcrypt.MaxCost {
 		cost = val
-	} else {
-		cost = bcrypt.DefaultCost
+	}
+	if val, err := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32); err == nil && val >= 8*1024 {
+		params.memory = uint32(val)
+	}
+	if val, err := strconv.ParseUint(os.Getenv("ARGON2_TIME"), 10, 32); err == nil && val > 0 {
+		params.time = uint32(val)
+	}
+	if val, err := strconv.ParseUint(os.Getenv("ARGON2_THREADS"), 10, 8); err == nil && val > 0 {
+		params.threads = uint8(val)
 	}
 }
 
-func GernerateHashPassword(password string) string {
-	if hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost); err != nil {
-		log.Logger.Error().Err(err).Msg("failed to generate hash password")
-		return ""
-	} else {
-		return string(hashPassword)
+// GernerateHashPassword 使用当前配置的算法与参数计算密码哈希
+// argon2id 的结果为 PHC 格式：$argon2id$v=19$m=65536,t=3,p=4$<盐>$<哈希>
+func GernerateHashPassword(password string) (string, error) {
+	if hashAlg == HashBcrypt {
+		hashPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashPassword), nil
+	}
+	salt := make([]byte, params.saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
 	}
+	key := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, params.keyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.memory, params.time, params.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
+// CompareHashPassword 校验密码，根据哈希的格式选择 argon2id 或 bcrypt
 func CompareHashPassword(hashPassword, password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password)) == nil
+	if !strings.HasPrefix(hashPassword, "$argon2id$") {
+		return bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(password)) == nil
+	}
+	p, salt, key, err := decodeArgon2(hashPassword)
+	if err != nil {
+		return false
+	}
+	other := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, p.keyLen)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
+
+// NeedsRehash 哈希的算法或参数与当前配置不一致时返回 true，应在密码校验通过后重新计算
+func NeedsRehash(hashPassword string) bool {
+	if hashAlg == HashBcrypt {
+		hashCost, err := bcrypt.Cost([]byte(hashPassword))
+		return err != nil || hashCost != cost
+	}
+	p, salt, _, err := decodeArgon2(hashPassword)
+	if err != nil {
+		return true
+	}
+	return p.memory != params.memory || p.time != params.time || p.threads != params.threads ||
+		p.keyLen != params.keyLen || len(salt) != params.saltLen
+}
+
+func decodeArgon2(hashPassword string) (*argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hashPassword, "$")
+	if len(parts) != 6 || parts[1] != HashArgon2id {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	p := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil || p.time == 0 || p.threads == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	p.saltLen = len(salt)
+	p.keyLen = uint32(len(key))
+	return p, salt, key, nil
 }
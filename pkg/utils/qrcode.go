@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"Gin-IM/pkg/defines"
+	"encoding/base64"
+	"github.com/skip2/go-qrcode"
+)
+
+// GenerateQRCodeBase64 将内容编码为二维码，并以 data URI 形式的 base64 PNG 返回，与验证码图片的返回格式一致
+// 纠错等级为 M，尺寸为负数时表示每个模块占用的像素数，图片大小随内容长度变化
+func GenerateQRCodeBase64(content string) (string, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, -defines.QR_CODE_SCALE)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
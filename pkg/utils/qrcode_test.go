@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestGenerateQRCodeBase64(t *testing.T) {
+	uri, err := GenerateQRCodeBase64("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := strings.CutPrefix(uri, "data:image/png;base64,")
+	if !ok {
+		t.Fatalf("unexpected data uri %.40s", uri)
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 版本 1 为 21 个模块，两侧各有 4 个模块的静区
+	if size := img.Bounds().Dx(); size != (21+8)*8 || img.Bounds().Dy() != size {
+		t.Errorf("image size = %v, want %d", img.Bounds(), (21+8)*8)
+	}
+}
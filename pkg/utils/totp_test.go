@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 4226 与 RFC 6238 附录中 SHA1 测试使用的密钥 "12345678901234567890"
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestHOTP RFC 4226 附录 D 的测试向量
+func TestHOTP(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		if got := hotp([]byte("12345678901234567890"), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// TestVerifyTOTP RFC 6238 附录 B 的 SHA1 测试向量，取8位结果的后6位
+func TestVerifyTOTP(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		step, ok := VerifyTOTP(rfcSecret, tt.code, now, 0)
+		if !ok {
+			t.Errorf("VerifyTOTP(%d, %s) failed", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("VerifyTOTP(%d) step = %d, want %d", tt.unix, step, want)
+		}
+		// 同一时间步不能重复使用
+		if _, ok := VerifyTOTP(rfcSecret, tt.code, now, step); ok {
+			t.Errorf("VerifyTOTP(%d) accepted a reused step", tt.unix)
+		}
+	}
+}
+
+func TestVerifyTOTPSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := hotp([]byte("12345678901234567890"), now.Unix()/totpPeriod)
+	if _, ok := VerifyTOTP(rfcSecret, code, now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Error("code from the previous step should be accepted")
+	}
+	if _, ok := VerifyTOTP(rfcSecret, code, now.Add(2*totpPeriod*time.Second), 0); ok {
+		t.Error("code older than the allowed skew should be rejected")
+	}
+	if _, ok := VerifyTOTP(strings.ToLower(rfcSecret), code, now, 0); !ok {
+		t.Error("lower case secret should be accepted")
+	}
+	if _, ok := VerifyTOTP(rfcSecret, "12345", now, 0); ok {
+		t.Error("short code should be rejected")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("secret %q decodes to %d bytes, err %v", secret, len(key), err)
+	}
+	uri := TOTPProvisioningURI("Gin-IM", "a@b.c", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Gin-IM:a@b.c?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("unexpected provisioning uri %s", uri)
+	}
+}
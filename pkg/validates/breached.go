@@ -0,0 +1,126 @@
+package validates
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"io"
+	"os"
+	"sort"
+)
+
+// BreachedMagic 泄露密码文件的文件头
+// 文件头之后是按升序排列、大端序保存的 SHA-1 哈希前 8 字节，每条 8 字节，可以由 cmd/breached 生成。
+// 只保存哈希前缀可以让文件体积减少到完整哈希的五分之二，误判的概率约为 2^-64 乘以条目数，可以忽略。
+const BreachedMagic = "GIMBRCH1"
+
+// breachedFile 已打开的泄露密码文件，查询时在文件中二分查找，不把整个列表读入内存
+type breachedFile struct {
+	file  *os.File
+	count int64
+}
+
+// breached 未调用 LoadBreached 或未配置文件时为 nil，此时不做泄露检查
+var breached *breachedFile
+
+var errBreachedFile = errors.New("泄露密码文件格式错误")
+
+// LoadBreached 打开泄露密码文件并检查格式，path 为空时不启用泄露检查
+// 文件会一直保持打开，启动时顺序读取一遍，确认条目按升序排列，之后每次查询只读取 log2(条目数) 条
+func LoadBreached(path string) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	count, err := checkBreached(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	breached = &breachedFile{file: file, count: count}
+	log.Logger.Info().Int64("count", count).Msg("已加载泄露密码列表")
+	return nil
+}
+
+// checkBreached 校验文件头、长度与排序，返回条目数
+func checkBreached(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size() - int64(len(BreachedMagic))
+	if size < 0 || size%8 != 0 {
+		return 0, errBreachedFile
+	}
+	reader := bufio.NewReaderSize(file, 1<<20)
+	magic := make([]byte, len(BreachedMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return 0, err
+	}
+	if string(magic) != BreachedMagic {
+		return 0, errBreachedFile
+	}
+	var buf [8]byte
+	var last uint64
+	for i := int64(0); i < size/8; i++ {
+		if _, err := io.ReadFull(reader, buf[:]); err != nil {
+			return 0, err
+		}
+		prefix := binary.BigEndian.Uint64(buf[:])
+		if i > 0 && prefix < last {
+			return 0, errBreachedFile
+		}
+		last = prefix
+	}
+	return size / 8, nil
+}
+
+// at 读取第 i 条哈希前缀
+func (b *breachedFile) at(i int64) (uint64, error) {
+	var buf [8]byte
+	if _, err := b.file.ReadAt(buf[:], int64(len(BreachedMagic))+i*8); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// contains 在文件中二分查找哈希前缀，读取失败时视为不存在
+func (b *breachedFile) contains(prefix uint64) bool {
+	var readErr error
+	i := sort.Search(int(b.count), func(i int) bool {
+		value, err := b.at(int64(i))
+		if err != nil {
+			readErr = err
+			return true
+		}
+		return value >= prefix
+	})
+	if readErr != nil {
+		log.Logger.Error().Err(readErr).Msg("读取泄露密码文件失败")
+		return false
+	}
+	if int64(i) == b.count {
+		return false
+	}
+	value, err := b.at(int64(i))
+	return err == nil && value == prefix
+}
+
+// BreachedPrefix 返回密码 SHA-1 哈希的前 8 字节
+func BreachedPrefix(password string) uint64 {
+	sum := sha1.Sum([]byte(password))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// IsBreached 密码是否出现在泄露密码列表中
+func IsBreached(password string) bool {
+	if breached == nil || breached.count == 0 {
+		return false
+	}
+	return breached.contains(BreachedPrefix(password))
+}
@@ -0,0 +1,124 @@
+package validates
+
+import (
+	"Gin-IM/pkg/utils"
+	"fmt"
+	_ "github.com/joho/godotenv/autoload"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultSymbols 默认允许的符号，包括空格与 ASCII 中所有的标点符号
+const defaultSymbols = " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// bcryptMaxBytes bcrypt 只使用密码的前 72 个字节，超过的部分不会参与校验，argon2id 没有这个限制
+const bcryptMaxBytes = 72
+
+// PasswordPolicy 密码策略，由环境变量配置
+// PASSWORD_MIN_LENGTH 与 PASSWORD_MAX_LENGTH 为字符数，默认 8 与 64；
+// PASSWORD_REQUIRE_LOWER、PASSWORD_REQUIRE_UPPER、PASSWORD_REQUIRE_DIGIT 默认为 true，PASSWORD_REQUIRE_SYMBOL 默认为 false；
+// PASSWORD_SYMBOLS 为允许的符号，默认为空格与 ASCII 标点符号。密码中只能出现英文字母、数字与允许的符号。
+// PASSWORD_HASH_ALG=bcrypt 时密码不能超过 72 个字节。
+type PasswordPolicy struct {
+	MinLength     int
+	MaxLength     int
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	Symbols       string
+	// MaxBytes 密码的最大字节数，为 0 时不限制
+	MaxBytes int
+}
+
+var Password = loadPasswordPolicy()
+
+func loadPasswordPolicy() *PasswordPolicy {
+	policy := &PasswordPolicy{
+		MinLength:     envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:     envInt("PASSWORD_MAX_LENGTH", 64),
+		RequireLower:  envBool("PASSWORD_REQUIRE_LOWER", true),
+		RequireUpper:  envBool("PASSWORD_REQUIRE_UPPER", true),
+		RequireDigit:  envBool("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol: envBool("PASSWORD_REQUIRE_SYMBOL", false),
+		Symbols:       defaultSymbols,
+	}
+	if symbols, ok := os.LookupEnv("PASSWORD_SYMBOLS"); ok {
+		policy.Symbols = symbols
+	}
+	policy.MinLength = max(policy.MinLength, 1)
+	policy.MaxLength = max(policy.MaxLength, policy.MinLength)
+	if os.Getenv("PASSWORD_HASH_ALG") == utils.HashBcrypt {
+		policy.MaxBytes = bcryptMaxBytes
+		policy.MaxLength = min(policy.MaxLength, bcryptMaxBytes)
+	}
+	return policy
+}
+
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func envBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// Check 检查密码是否符合策略
+func (p *PasswordPolicy) Check(password string) bool {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength || length > p.MaxLength || (p.MaxBytes > 0 && len(password) > p.MaxBytes) {
+		return false
+	}
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case strings.ContainsRune(p.Symbols, r):
+			symbol = true
+		default:
+			return false
+		}
+	}
+	return (lower || !p.RequireLower) && (upper || !p.RequireUpper) &&
+		(digit || !p.RequireDigit) && (symbol || !p.RequireSymbol)
+}
+
+// Describe 返回策略的说明，用于校验失败时的提示
+func (p *PasswordPolicy) Describe() string {
+	var classes []string
+	if p.RequireLower {
+		classes = append(classes, "小写字母")
+	}
+	if p.RequireUpper {
+		classes = append(classes, "大写字母")
+	}
+	if p.RequireDigit {
+		classes = append(classes, "数字")
+	}
+	if p.RequireSymbol {
+		classes = append(classes, "符号")
+	}
+	description := fmt.Sprintf("密码长度应是%d~%d位", p.MinLength, p.MaxLength)
+	if len(classes) > 0 {
+		description += "且包含" + strings.Join(classes, "、")
+	}
+	if symbols := strings.ReplaceAll(p.Symbols, " ", ""); symbols != "" {
+		description += "，可以使用的符号为 " + symbols
+	} else if p.Symbols == "" {
+		description += "，只能使用字母与数字"
+	}
+	return description
+}
@@ -1,20 +1,26 @@
 package validates
 
 import (
-	"Gin-IM/pkg/defines"
-	"github.com/dlclark/regexp2"
 	"github.com/go-playground/validator/v10"
 )
 
 var rules = map[string]func(fl validator.FieldLevel) bool{}
 
+// ruleMessages 提示内容随配置变化的规则，校验失败时代替字段上的 field_error_info
+var ruleMessages = map[string]func() string{}
+
 func init() {
 	rules["pass"] = checkPassword
+	rules["notbreached"] = checkNotBreached
+	ruleMessages["pass"] = Password.Describe
+	ruleMessages["notbreached"] = func() string {
+		return "该密码已在公开的泄露数据中出现，请更换其他密码"
+	}
 }
 func checkPassword(fl validator.FieldLevel) bool {
-	re := regexp2.MustCompile(defines.PASSWORD_REGEX, 0)
-	if isMatch, _ := re.MatchString(fl.Field().String()); isMatch {
-		return true
-	}
-	return false
+	return Password.Check(fl.Field().String())
+}
+
+func checkNotBreached(fl validator.FieldLevel) bool {
+	return !IsBreached(fl.Field().String())
 }
@@ -33,6 +33,9 @@ func processError(data interface{}, err error) string {
 	if errors.As(err, &validationErrs) {
 		for _, validationError := range validationErrs {
 			fieldName := validationError.Field()
+			if message, ok := ruleMessages[validationError.Tag()]; ok {
+				return fmt.Sprintf("%s : %s", fieldName, message())
+			}
 			typeOf := reflect.TypeOf(data)
 			if typeOf.Kind() == reflect.Pointer {
 				typeOf = typeOf.Elem()